@@ -0,0 +1,135 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// operation runs one operation of a benchmark, it returns the output size.
+type operation func() (int, error)
+
+// benchmark is a benchmark of an operation in one mode, implemented by the hardware or the software.
+type benchmark struct {
+	op   string // e.g. "compress", "crc"
+	mode string // e.g. "dynamic", "crc32-ieee"
+	impl string // e.g. "iaa", "compress/flate"
+	poll string // "busy" or "yield", empty if the polling mode can't be configured
+	size int    // the input size
+
+	// setup creates the operation run by a worker, every worker has its own operation.
+	setup func() (operation, error)
+}
+
+// Result is the result of a benchmark.
+type Result struct {
+	Op          string  `json:"op"`
+	Mode        string  `json:"mode"`
+	Impl        string  `json:"impl"`
+	Poll        string  `json:"poll,omitempty"`
+	Selector    string  `json:"selector,omitempty"`
+	Size        int     `json:"size"`
+	Concurrency int     `json:"concurrency"`
+	Ops         int64   `json:"ops"`
+	Throughput  float64 `json:"throughput_mbps"` // MB/s of the input
+	OpsPerSec   float64 `json:"ops_per_sec"`
+	Ratio       float64 `json:"ratio,omitempty"` // the input size divided by the output size, only for compression
+	P50         float64 `json:"p50_us"`          // the latency percentiles in microseconds
+	P90         float64 `json:"p90_us"`
+	P99         float64 `json:"p99_us"`
+	Max         float64 `json:"max_us"`
+	Error       string  `json:"error,omitempty"` // the error that stopped the benchmark
+}
+
+// run runs the benchmark by `concurrency` workers for `duration`.
+func (b *benchmark) run(concurrency int, duration time.Duration) Result {
+	result := Result{
+		Op:          b.op,
+		Mode:        b.mode,
+		Impl:        b.impl,
+		Poll:        b.poll,
+		Size:        b.size,
+		Concurrency: concurrency,
+	}
+	ops := make([]operation, concurrency)
+	for i := range ops {
+		op, err := b.setup()
+		if err == nil {
+			// warm up, and make sure the operation works
+			_, err = op()
+		}
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		ops[i] = op
+	}
+
+	latencies := make([][]time.Duration, concurrency)
+	outputs := make([]int64, concurrency)
+	errs := make([]error, concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range ops {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				t := time.Now()
+				if t.Sub(start) >= duration {
+					return
+				}
+				n, err := ops[i]()
+				latencies[i] = append(latencies[i], time.Since(t))
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				outputs[i] += int64(n)
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var all []time.Duration
+	var output int64
+	for i := range latencies {
+		all = append(all, latencies[i]...)
+		output += outputs[i]
+		if errs[i] != nil && result.Error == "" {
+			result.Error = errs[i].Error()
+		}
+	}
+	result.Ops = int64(len(all))
+	result.OpsPerSec = float64(result.Ops) / elapsed.Seconds()
+	result.Throughput = result.OpsPerSec * float64(b.size) / 1e6
+	if b.op == opCompress && output != 0 {
+		result.Ratio = float64(result.Ops) * float64(b.size) / float64(output)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	result.P50 = microseconds(percentile(all, 50))
+	result.P90 = microseconds(percentile(all, 90))
+	result.P99 = microseconds(percentile(all, 99))
+	result.Max = microseconds(percentile(all, 100))
+	return result
+}
+
+// percentile returns the p-th percentile of the sorted latencies using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
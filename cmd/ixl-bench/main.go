@@ -0,0 +1,300 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Command ixl-bench benchmarks the operations accelerated by IAA and DSA, and their software counterparts
+// (compress/flate, hash/crc32, hash/crc64, plain Go loops and copy()):
+//
+//	ixl-bench -ops compress,crc -sizes 4K,64K,1M -concurrency 1,8 -duration 2s -json
+//
+// Every operation is benchmarked with all the combinations of the sizes and the concurrency levels,
+// the hardware operations supporting it are benchmarked in both the busy-poll and the yield mode.
+// The throughput, the operations per second and the latency percentiles are reported.
+//
+// The work queues are selected by the IAA_WQ_SELECTOR and DSA_WQ_SELECTOR environment variables
+// when the devices are loaded, so the hardware benchmarks of every selector given by -selectors
+// are run in a child process.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/datamove"
+)
+
+type config struct {
+	ops         []string
+	sizes       []int
+	concurrency []int
+	polls       []string
+	selectors   []string
+	duration    time.Duration
+	software    bool
+	hardware    bool
+	json        bool
+
+	childArgs []string // the arguments of the child processes running the selectors
+}
+
+// Report is the JSON output of ixl-bench.
+type Report struct {
+	GoVersion string   `json:"go_version"`
+	GOOS      string   `json:"goos"`
+	GOARCH    string   `json:"goarch"`
+	NumCPU    int      `json:"num_cpu"`
+	Results   []Result `json:"results"`
+}
+
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	c := &config{}
+	flags := flag.NewFlagSet("ixl-bench", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	ops := flags.String("ops", strings.Join(allOps, ","), "the operations to benchmark")
+	sizes := flags.String("sizes", "4K,64K,1M", "the input sizes, the K, M and G suffixes are supported")
+	concurrency := flags.String("concurrency", "1,"+strconv.Itoa(runtime.NumCPU()), "the numbers of concurrent goroutines")
+	polls := flags.String("poll", pollBusy+","+pollYield, "the polling modes of the hardware operations supporting them")
+	selectors := flags.String("selectors", "", "the work queue selectors separated by ';', e.g. '0.0;1.*'")
+	flags.DurationVar(&c.duration, "duration", time.Second, "the duration of every benchmark")
+	flags.BoolVar(&c.software, "software", true, "benchmark the software implementations")
+	flags.BoolVar(&c.hardware, "hardware", true, "benchmark the hardware implementations")
+	flags.BoolVar(&c.json, "json", false, "print the results in JSON")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+
+	var err error
+	if c.ops, err = parseList(*ops, allOps); err != nil {
+		return nil, err
+	}
+	if c.polls, err = parseList(*polls, []string{pollBusy, pollYield}); err != nil {
+		return nil, err
+	}
+	if c.sizes, err = parseSizes(*sizes); err != nil {
+		return nil, err
+	}
+	if c.concurrency, err = parseInts(*concurrency); err != nil {
+		return nil, err
+	}
+	if c.duration <= 0 {
+		return nil, fmt.Errorf("invalid duration %v", c.duration)
+	}
+	for _, s := range strings.Split(*selectors, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			c.selectors = append(c.selectors, s)
+		}
+	}
+	if len(c.selectors) != 0 {
+		// the children run the hardware benchmarks only
+		c.childArgs = []string{"-json", "-software=false"}
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "selectors", "json", "software":
+			default:
+				c.childArgs = append(c.childArgs, "-"+f.Name+"="+f.Value.String())
+			}
+		})
+	}
+	return c, nil
+}
+
+// parseList parses the comma separated values, all the values must be valid.
+func parseList(s string, valid []string) ([]string, error) {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, name := range valid {
+			found = found || name == v
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown value %q, expected %s", v, strings.Join(valid, ","))
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty list %q", s)
+	}
+	return values, nil
+}
+
+// parseSizes parses the comma separated sizes like "4K,1M".
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		unit := 1
+		switch {
+		case strings.HasSuffix(v, "K"):
+			unit = 1 << 10
+		case strings.HasSuffix(v, "M"):
+			unit = 1 << 20
+		case strings.HasSuffix(v, "G"):
+			unit = 1 << 30
+		}
+		if unit != 1 {
+			v = v[:len(v)-1]
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid size %q", s)
+		}
+		sizes = append(sizes, n*unit)
+	}
+	return sizes, nil
+}
+
+// parseInts parses the comma separated positive integers.
+func parseInts(s string) ([]int, error) {
+	var values []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// formatSize formats the size like parseSizes accepts.
+func formatSize(size int) string {
+	for _, u := range []struct {
+		suffix string
+		unit   int
+	}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}} {
+		if size >= u.unit && size%u.unit == 0 {
+			return strconv.Itoa(size/u.unit) + u.suffix
+		}
+	}
+	return strconv.Itoa(size)
+}
+
+// runBenchmarks runs the benchmarks in this process.
+func (c *config) runBenchmarks() []Result {
+	var results []Result
+	for _, op := range c.ops {
+		for _, size := range c.sizes {
+			for _, b := range c.benchmarks(op, size) {
+				for _, concurrency := range c.concurrency {
+					results = append(results, b.run(concurrency, c.duration))
+				}
+			}
+		}
+	}
+	return results
+}
+
+// runSelector runs the hardware benchmarks in a child process using the work queues selected by the selector.
+func (c *config) runSelector(selector string, stderr io.Writer) ([]Result, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(exe, c.childArgs...)
+	cmd.Env = append(os.Environ(), "IAA_WQ_SELECTOR="+selector, "DSA_WQ_SELECTOR="+selector)
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err = json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+	for i := range report.Results {
+		report.Results[i].Selector = selector
+	}
+	return report.Results, nil
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c, err := parseArgs(args, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "ixl-bench:", err)
+		return 2
+	}
+	report := Report{
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+	}
+	if c.hardware && !compress.Ready() && !datamove.Ready() {
+		fmt.Fprintln(stderr, "ixl-bench: IAA and DSA devices not found, only the software implementations are benchmarked")
+	}
+	if len(c.selectors) == 0 {
+		report.Results = c.runBenchmarks()
+	} else {
+		hardware := c.hardware
+		c.hardware = false
+		report.Results = c.runBenchmarks()
+		for _, selector := range c.selectors {
+			if !hardware {
+				break
+			}
+			results, err := c.runSelector(selector, stderr)
+			if err != nil {
+				fmt.Fprintf(stderr, "ixl-bench: selector %q: %v\n", selector, err)
+				return 1
+			}
+			report.Results = append(report.Results, results...)
+		}
+	}
+
+	if c.json {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintln(stderr, "ixl-bench:", err)
+			return 1
+		}
+		return 0
+	}
+	printText(stdout, report.Results)
+	return 0
+}
+
+func printText(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tmode\timpl\tpoll\tselector\tsize\tgoroutines\tops\tMB/s\tops/s\tp50(us)\tp90(us)\tp99(us)\tmax(us)\tratio\terror\t")
+	for _, r := range results {
+		ratio := "-"
+		if r.Ratio != 0 {
+			ratio = fmt.Sprintf("%.2f", r.Ratio)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%.1f\t%.0f\t%.1f\t%.1f\t%.1f\t%.1f\t%s\t%s\t\n",
+			r.Op, r.Mode, r.Impl, orDash(r.Poll), orDash(r.Selector), formatSize(r.Size), r.Concurrency, r.Ops,
+			r.Throughput, r.OpsPerSec, r.P50, r.P90, r.P99, r.Max, ratio, orDash(r.Error))
+	}
+	tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := parseSizes("100, 4K,2m,1G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sizes, []int{100, 4 << 10, 2 << 20, 1 << 30}) {
+		t.Fatalf("unexpected sizes %v", sizes)
+	}
+	for _, size := range sizes {
+		if s, _ := parseSizes(formatSize(size)); s[0] != size {
+			t.Fatalf("unexpected size %v formatted as %s", s, formatSize(size))
+		}
+	}
+	for _, s := range []string{"", "K", "-1K", "1T", "0"} {
+		if _, err := parseSizes(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	stderr := bytes.NewBuffer(nil)
+	for _, args := range [][]string{
+		{"-ops", "compress,zip"},
+		{"-poll", "spin"},
+		{"-concurrency", "0"},
+		{"-duration", "0s"},
+		{"extra"},
+	} {
+		if _, err := parseArgs(args, stderr); err == nil {
+			t.Fatalf("expected error for %q", args)
+		}
+	}
+	c, err := parseArgs([]string{"-ops", "crc", "-sizes", "1M", "-selectors", "0.0;1.*", "-json"}, stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.selectors, []string{"0.0", "1.*"}) {
+		t.Fatalf("unexpected selectors %q", c.selectors)
+	}
+	expected := []string{"-json", "-software=false", "-ops=crc", "-sizes=1M"}
+	if !reflect.DeepEqual(c.childArgs, expected) {
+		t.Fatalf("unexpected child arguments %q", c.childArgs)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 200; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	for p, expected := range map[int]time.Duration{0: 1, 50: 100, 99: 198, 100: 200} {
+		if d := percentile(sorted, p); d != expected {
+			t.Fatalf("expected %v for p%d, got %v", expected, p, d)
+		}
+	}
+	if percentile(nil, 50) != 0 {
+		t.Fatal("expected 0 for empty latencies")
+	}
+}
+
+func TestRun(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	status := run([]string{"-hardware=false", "-sizes", "4K", "-concurrency", "1,2", "-duration", "10ms", "-json"}, stdout, stderr)
+	if status != 0 {
+		t.Fatalf("unexpected status %d: %s", status, stderr)
+	}
+	var report Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	// 4 modes of compress and decompress, 5 CRCs, 3 filters and copy
+	if len(report.Results) != (4+4+5+3+1)*2 {
+		t.Fatalf("unexpected number of results %d", len(report.Results))
+	}
+	for _, r := range report.Results {
+		if r.Error != "" || r.Ops == 0 || r.Throughput <= 0 || r.P50 > r.Max {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		if (r.Op == opCompress) != (r.Ratio != 0) {
+			t.Fatalf("unexpected ratio %+v", r)
+		}
+	}
+}
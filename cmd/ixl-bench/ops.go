@@ -0,0 +1,370 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"math/rand"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/crc"
+	"github.com/intel/ixl-go/datamove"
+	"github.com/intel/ixl-go/filter"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+// The operations can be benchmarked.
+const (
+	opCompress   = "compress"
+	opDecompress = "decompress"
+	opCRC        = "crc"
+	opFilter     = "filter"
+	opCopy       = "copy"
+)
+
+var allOps = []string{opCompress, opDecompress, opCRC, opFilter, opCopy}
+
+// The implementations.
+const (
+	implIAA      = "iaa"
+	implDSA      = "dsa"
+	implFlate    = "compress/flate"
+	implCRC32    = "hash/crc32"
+	implCRC64    = "hash/crc64"
+	implGo       = "go"
+	implBuiltin  = "copy()"
+	pollBusy     = "busy"
+	pollYield    = "yield"
+	compressible = 3 // the compression ratio of the generated data
+)
+
+// benchmarks returns the benchmarks of the operation,
+// the hardware benchmarks are only returned if the devices are found.
+func (c *config) benchmarks(op string, size int) []*benchmark {
+	var bs []*benchmark
+	switch op {
+	case opCompress, opDecompress:
+		bs = c.compressBenchmarks(op, size)
+	case opCRC:
+		bs = c.crcBenchmarks(size)
+	case opFilter:
+		bs = c.filterBenchmarks(size)
+	case opCopy:
+		bs = c.copyBenchmarks(size)
+	}
+	var selected []*benchmark
+	for _, b := range bs {
+		b.op = op
+		b.size = size
+		software := b.impl != implIAA && b.impl != implDSA
+		if (software && c.software) || (!software && c.hardware) {
+			selected = append(selected, b)
+		}
+	}
+	return selected
+}
+
+var compressModes = []struct {
+	name  string
+	level int
+}{
+	{"stored", compress.NoCompression},
+	{"fixed", compress.BestSpeed},
+	{"dynamic", compress.DefaultCompression},
+	{"huffman-only", compress.HuffmanOnlyLevel},
+}
+
+func (c *config) compressBenchmarks(op string, size int) []*benchmark {
+	var bs []*benchmark
+	data := testutil.RandomByRatio(size, compressible)
+	for _, m := range compressModes {
+		level := m.level
+		if compress.Ready() {
+			for _, poll := range c.polls {
+				opts := []compress.Option{compress.Level(level)}
+				if poll == pollBusy {
+					opts = append(opts, compress.BusyPoll())
+				}
+				b := &benchmark{mode: m.name, impl: implIAA, poll: poll}
+				if op == opCompress {
+					b.setup = func() (operation, error) { return deflateOp(data, opts) }
+				} else {
+					b.setup = func() (operation, error) { return inflateOp(data, opts) }
+				}
+				bs = append(bs, b)
+			}
+		}
+		b := &benchmark{mode: m.name, impl: implFlate}
+		if op == opCompress {
+			b.setup = func() (operation, error) { return flateOp(data, level) }
+		} else {
+			b.setup = func() (operation, error) { return flateReaderOp(data, level) }
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+func deflateOp(data []byte, opts []compress.Option) (operation, error) {
+	d, err := compress.NewDeflate(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	dst := make([]byte, 0, compress.CompressBound(len(data)))
+	return func() (int, error) {
+		out, err := d.CompressAll(dst[:0], data)
+		return len(out), err
+	}, nil
+}
+
+func inflateOp(data []byte, opts []compress.Option) (operation, error) {
+	// the data must be compressed by IAA, the window of compress/flate is too large
+	d, err := compress.NewDeflate(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := d.CompressAll(nil, data)
+	if err != nil {
+		return nil, err
+	}
+	i, err := compress.NewInflate(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, len(data))
+	return func() (int, error) {
+		n, err := i.DecompressAll(compressed, raw)
+		if err == nil && n != len(data) {
+			err = fmt.Errorf("unexpected decompressed size %d", n)
+		}
+		return n, err
+	}, nil
+}
+
+func flateOp(data []byte, level int) (operation, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, compress.CompressBound(len(data))))
+	w, err := flate.NewWriter(buf, level)
+	if err != nil {
+		return nil, err
+	}
+	return func() (int, error) {
+		buf.Reset()
+		w.Reset(buf)
+		if _, err := w.Write(data); err != nil {
+			return 0, err
+		}
+		err := w.Close()
+		return buf.Len(), err
+	}, nil
+}
+
+func flateReaderOp(data []byte, level int) (operation, error) {
+	compressed := bytes.NewBuffer(nil)
+	w, err := flate.NewWriter(compressed, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	src := bytes.NewReader(compressed.Bytes())
+	r := flate.NewReader(src)
+	raw := make([]byte, len(data))
+	return func() (int, error) {
+		src.Reset(compressed.Bytes())
+		if err := r.(flate.Resetter).Reset(src, nil); err != nil {
+			return 0, err
+		}
+		return io.ReadFull(r, raw)
+	}, nil
+}
+
+func (c *config) crcBenchmarks(size int) []*benchmark {
+	data := testutil.RandomByRatio(size, 1)
+	var bs []*benchmark
+	iaaCRC := func(mode string, sum func(calc *crc.Calculator) error) {
+		if !crc.Ready() {
+			return
+		}
+		bs = append(bs, &benchmark{mode: mode, impl: implIAA, setup: func() (operation, error) {
+			calc, err := crc.NewCalculator()
+			if err != nil {
+				return nil, err
+			}
+			return func() (int, error) { return 0, sum(calc) }, nil
+		}})
+	}
+	software := func(mode, impl string, sum func()) {
+		bs = append(bs, &benchmark{mode: mode, impl: impl, setup: func() (operation, error) {
+			return func() (int, error) {
+				sum()
+				return 0, nil
+			}, nil
+		}})
+	}
+
+	for _, p := range []struct {
+		name string
+		poly uint64
+	}{{"crc64-iso", crc.ISO}, {"crc64-ecma", crc.ECMA}} {
+		poly := p.poly
+		iaaCRC(p.name, func(calc *crc.Calculator) error {
+			_, err := calc.CheckSum64(data, poly)
+			return err
+		})
+		table := crc64.MakeTable(poly)
+		software(p.name, implCRC64, func() { crc64.Checksum(data, table) })
+	}
+	for _, p := range []struct {
+		name string
+		poly uint32
+	}{{"crc32-ieee", crc.IEEE}, {"crc32-castagnoli", crc.Castagnoli}, {"crc32-koopman", crc.Koopman}} {
+		poly := p.poly
+		iaaCRC(p.name, func(calc *crc.Calculator) error {
+			_, err := calc.CheckSum32(data, poly)
+			return err
+		})
+		table := crc32.MakeTable(poly)
+		software(p.name, implCRC32, func() { crc32.Checksum(data, table) })
+	}
+	for _, p := range []struct {
+		name string
+		poly uint16
+	}{{"crc16-ccitt", crc.CCITT}, {"crc16-t10dif", crc.T10DIF}} {
+		poly := p.poly
+		iaaCRC(p.name, func(calc *crc.Calculator) error {
+			_, err := calc.CheckSum16(data, poly)
+			return err
+		})
+	}
+
+	// CRC32C is also calculated by DSA
+	if datamove.Ready() {
+		for _, poll := range c.polls {
+			var opts []crc.CRC32COption
+			if poll == pollYield {
+				opts = append(opts, crc.YieldProcessor)
+			}
+			bs = append(bs, &benchmark{mode: "crc32-castagnoli", impl: implDSA, poll: poll, setup: func() (operation, error) {
+				h, err := crc.NewCRC32C(opts...)
+				if err != nil {
+					return nil, err
+				}
+				return func() (int, error) {
+					h.Reset()
+					_, err := h.Write(data)
+					return 0, err
+				}, nil
+			}})
+		}
+	}
+	return bs
+}
+
+// filterRange is the range scanned, about half of the generated values are in the range.
+var filterRange = filter.Range[uint32]{Min: 0, Max: 127}
+
+func (c *config) filterBenchmarks(size int) []*benchmark {
+	values := make([]uint32, size/4)
+	rng := rand.New(rand.NewSource(1))
+	for i := range values {
+		values[i] = uint32(rng.Intn(256))
+	}
+	set := scanGo(values)
+	selected := selectGo(values, set)
+
+	var bs []*benchmark
+	if filter.Ready() {
+		iaaFilter := func(mode string, op func(ctx *filter.Context) error) {
+			bs = append(bs, &benchmark{mode: mode, impl: implIAA, setup: func() (operation, error) {
+				ctx, err := filter.NewContext()
+				if err != nil {
+					return nil, err
+				}
+				return func() (int, error) { return 0, op(ctx) }, nil
+			}})
+		}
+		iaaFilter("scan", func(ctx *filter.Context) error {
+			_, err := filter.Scan(ctx, values, filterRange)
+			return err
+		})
+		iaaFilter("select", func(ctx *filter.Context) error {
+			_, err := filter.Select(ctx, values, set)
+			return err
+		})
+		iaaFilter("expand", func(ctx *filter.Context) error {
+			_, err := filter.Expand(ctx, selected, set)
+			return err
+		})
+	}
+	software := func(mode string, op func()) {
+		bs = append(bs, &benchmark{mode: mode, impl: implGo, setup: func() (operation, error) {
+			return func() (int, error) {
+				op()
+				return 0, nil
+			}, nil
+		}})
+	}
+	software("scan", func() { scanGo(values) })
+	software("select", func() { selectGo(values, set) })
+	software("expand", func() { expandGo(selected, set, len(values)) })
+	return bs
+}
+
+func scanGo(values []uint32) filter.BitSet {
+	set := make(filter.BitSet, len(values)/8+1)
+	for i, v := range values {
+		if v >= filterRange.Min && v <= filterRange.Max {
+			set[i/8] |= 1 << (i % 8)
+		}
+	}
+	return set
+}
+
+func selectGo(values []uint32, set filter.BitSet) []uint32 {
+	result := make([]uint32, 0, len(values))
+	for i, v := range values {
+		if set[i/8]&(1<<(i%8)) != 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func expandGo(selected []uint32, set filter.BitSet, size int) []uint32 {
+	result := make([]uint32, size)
+	j := 0
+	for i := range result {
+		if set[i/8]&(1<<(i%8)) != 0 {
+			result[i] = selected[j]
+			j++
+		}
+	}
+	return result
+}
+
+func (c *config) copyBenchmarks(size int) []*benchmark {
+	src := testutil.RandomByRatio(size, 1)
+	var bs []*benchmark
+	if datamove.Ready() {
+		bs = append(bs, &benchmark{mode: "memmove", impl: implDSA, setup: func() (operation, error) {
+			ctx := datamove.NewContext()
+			dst := make([]byte, size)
+			return func() (int, error) { return size, ctx.CopyCheckError(dst, src) }, nil
+		}})
+	}
+	bs = append(bs, &benchmark{mode: "memmove", impl: implBuiltin, setup: func() (operation, error) {
+		dst := make([]byte, size)
+		return func() (int, error) { return copy(dst, src), nil }, nil
+	}})
+	return bs
+}
@@ -0,0 +1,432 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+// newWriter creates the gzip writer, the returned header is written by the first write.
+func (c *config) newWriter(w io.Writer) (io.WriteCloser, *compress.Header, error) {
+	switch {
+	case c.software:
+		zw, err := gzip.NewWriterLevel(w, c.level)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zw, &zw.Header, nil
+	case c.parallel:
+		p, err := compress.NewParallelGzip(w, compress.Level(c.level))
+		if err != nil {
+			return nil, nil, err
+		}
+		p.UTF8 = true
+		return p, &p.Header, nil
+	default:
+		g := compress.NewGzip(w, compress.Level(c.level))
+		g.UTF8 = true
+		return g, &g.Header, nil
+	}
+}
+
+// newReader creates the gzip reader, the header is read immediately.
+func (c *config) newReader(r io.Reader) (io.Reader, *compress.Header, error) {
+	if c.software {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, &zr.Header, nil
+	}
+	z, err := compress.NewGzipReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return z, &z.Header, nil
+}
+
+// copyCompressed compresses `src` into `dst` as a gzip member, it returns the sizes of the input and the output.
+func (c *config) copyCompressed(dst io.Writer, src io.Reader, name string, modTime time.Time) (in, out int64, err error) {
+	cw := &countWriter{w: dst}
+	w, hdr, err := c.newWriter(cw)
+	if err != nil {
+		return 0, 0, err
+	}
+	hdr.Name = name
+	hdr.ModTime = modTime
+	in, err = io.Copy(w, src)
+	if err != nil {
+		return in, cw.n, err
+	}
+	err = w.Close()
+	return in, cw.n, err
+}
+
+// copyDecompressed copies the decompressed data of `r` into `dst`, `src` is the underlying reader of `r`.
+//
+// If IAA fails to decompress the data (e.g. the window of the data is larger than 4KB),
+// the data is decompressed again from the beginning by compress/gzip, and the bytes written into `dst` are skipped.
+// `src` is rewound if it's a *recordReader or an io.Seeker, otherwise the error is returned.
+// The limit errors are returned without the fallback.
+func (c *config) copyDecompressed(dst io.Writer, r, src io.Reader) (n int64, err error) {
+	n, err = io.Copy(dst, r)
+	if _, ok := err.(*errors.LimitError); ok || c.software {
+		return n, err
+	}
+	if _, ok := err.(errors.Error); !ok {
+		return n, err
+	}
+	switch s := src.(type) {
+	case *recordReader:
+		if s.overflow {
+			return n, fmt.Errorf("%v (the standard input is too large to decompress again, use --software)", err)
+		}
+		src = s.replay()
+	case io.Seeker:
+		if _, serr := s.Seek(0, io.SeekStart); serr != nil {
+			return n, err
+		}
+	default:
+		return n, err
+	}
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return n, err
+	}
+	if _, err = io.CopyN(io.Discard, zr, n); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	m, err := io.Copy(dst, zr)
+	return n + m, err
+}
+
+// maxReplay is the max size of the data recorded by recordReader.
+const maxReplay = 1 << 20
+
+// recordReader records the data read from a reader which can't be rewound (e.g. the standard input),
+// so the data can be decompressed again by compress/gzip if IAA fails.
+// The data is dropped once it's larger than maxReplay, it can't be replayed then.
+type recordReader struct {
+	r        io.Reader
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (r *recordReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	switch {
+	case r.overflow:
+	case r.buf.Len()+n > maxReplay:
+		r.overflow = true
+		r.buf = bytes.Buffer{}
+	default:
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// replay returns a reader reading the data from the beginning.
+func (r *recordReader) replay() io.Reader {
+	return io.MultiReader(&r.buf, r.r)
+}
+
+func (c *config) compressFile(name string) error {
+	if name == "-" {
+		if !c.force && isTerminal(c.output) {
+			return warning("compressed data not written to a terminal, use -f to force compression")
+		}
+		_, _, err := c.copyCompressed(c.output, c.stdin, "", time.Time{})
+		return err
+	}
+	fi, err := c.statInput(name)
+	if err != nil {
+		return err
+	}
+	if !c.stdout && !c.force && strings.HasSuffix(name, c.suffix) {
+		return warning(fmt.Sprintf("already has %s suffix -- unchanged", c.suffix))
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var hdrName string
+	var hdrTime time.Time
+	if !c.noName {
+		hdrName = filepath.Base(name)
+		hdrTime = fi.ModTime()
+	}
+	if c.stdout {
+		inSize, outSize, err := c.copyCompressed(c.output, in, hdrName, hdrTime)
+		if err == nil && c.verbose {
+			fmt.Fprintf(c.log, "%s:\t%5.1f%%\n", name, ratio(outSize, inSize))
+		}
+		return err
+	}
+	output := name + c.suffix
+	var inSize, outSize int64
+	err = c.writeFile(output, fi.Mode(), fi.ModTime(), func(f *os.File) (err error) {
+		inSize, outSize, err = c.copyCompressed(f, in, hdrName, hdrTime)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if c.verbose {
+		fmt.Fprintf(c.log, "%s:\t%5.1f%% -- replaced with %s\n", name, ratio(outSize, inSize), output)
+	}
+	return c.removeInput(name)
+}
+
+func (c *config) decompressFile(name string) error {
+	if name == "-" {
+		src := c.stdin
+		if !c.software {
+			src = &recordReader{r: c.stdin}
+		}
+		r, _, err := c.newReader(src)
+		if err != nil {
+			return err
+		}
+		if c.test {
+			_, err = c.copyDecompressed(io.Discard, r, src)
+			return err
+		}
+		_, err = c.copyDecompressed(c.output, r, src)
+		return err
+	}
+	fi, err := c.statInput(name)
+	if err != nil {
+		return err
+	}
+	output := c.trimSuffix(name)
+	if output == "" && !c.stdout && !c.test {
+		return warning("unknown suffix -- ignored")
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	r, hdr, err := c.newReader(in)
+	if err != nil {
+		return err
+	}
+	if c.test {
+		_, err = c.copyDecompressed(io.Discard, r, in)
+		if err == nil && c.verbose {
+			fmt.Fprintf(c.log, "%s:\t OK\n", name)
+		}
+		return err
+	}
+	if c.stdout {
+		_, err = c.copyDecompressed(c.output, r, in)
+		return err
+	}
+
+	modTime := fi.ModTime()
+	if c.name {
+		if base := filepath.Base(hdr.Name); hdr.Name != "" && base != "." && base != ".." && base != string(filepath.Separator) {
+			output = filepath.Join(filepath.Dir(name), base)
+		}
+		if !hdr.ModTime.IsZero() {
+			modTime = hdr.ModTime
+		}
+	}
+	var outSize int64
+	err = c.writeFile(output, fi.Mode(), modTime, func(f *os.File) (err error) {
+		outSize, err = c.copyDecompressed(f, r, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if c.verbose {
+		fmt.Fprintf(c.log, "%s:\t%5.1f%% -- replaced with %s\n", name, ratio(fi.Size(), outSize), output)
+	}
+	return c.removeInput(name)
+}
+
+// trimSuffix returns the name of the decompressed file, it returns "" if the name has no known suffix.
+func (c *config) trimSuffix(name string) string {
+	for _, s := range []struct{ suffix, replace string }{
+		{c.suffix, ""},
+		{".gz", ""},
+		{".tgz", ".tar"},
+	} {
+		if base := strings.TrimSuffix(name, s.suffix); base != name && filepath.Base(base) != "" &&
+			!strings.HasSuffix(base, string(filepath.Separator)) {
+			return base + s.replace
+		}
+	}
+	return ""
+}
+
+// statInput returns the file info of the input file, only the regular files (or the links to them if forced) are accepted.
+func (c *config) statInput(name string) (os.FileInfo, error) {
+	fi, err := os.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 && (c.force || c.stdout) {
+		fi, err = os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, warning("is not a regular file -- ignored")
+	}
+	return fi, nil
+}
+
+// writeFile writes the output file by `write` atomically,
+// the file is created as a temporary file and renamed after `write` succeeds.
+func (c *config) writeFile(name string, mode os.FileMode, modTime time.Time, write func(f *os.File) error) error {
+	if _, err := os.Lstat(name); err == nil && !c.force {
+		return warning(fmt.Sprintf("%s already exists; not overwritten", name))
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".ixl-gzip-*")
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode.Perm())
+	}
+	if err == nil {
+		err = os.Chtimes(f.Name(), modTime, modTime)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (c *config) removeInput(name string) error {
+	if c.keep {
+		return nil
+	}
+	return os.Remove(name)
+}
+
+func (c *config) listFiles(files []string) int {
+	status := exitOK
+	fmt.Fprintf(c.output, "%19s %19s %6s %s\n", "compressed", "uncompressed", "ratio", "uncompressed_name")
+	var totalIn, totalOut int64
+	var listed int
+	for _, file := range files {
+		in, out, name, err := c.listFile(file)
+		if err != nil {
+			fmt.Fprintf(c.log, "ixl-gzip: %s: %v\n", file, err)
+			status = exitError
+			continue
+		}
+		fmt.Fprintf(c.output, "%19d %19d %5.1f%% %s\n", in, out, ratio(in, out), name)
+		totalIn += in
+		totalOut += out
+		listed++
+	}
+	if listed > 1 {
+		fmt.Fprintf(c.output, "%19d %19d %5.1f%% %s\n", totalIn, totalOut, ratio(totalIn, totalOut), "(totals)")
+	}
+	return status
+}
+
+// listFile returns the compressed size, the uncompressed size (recorded in the trailer) and the uncompressed name of the file.
+func (c *config) listFile(name string) (in, out int64, uncompressed string, err error) {
+	var r io.Reader = c.stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		defer f.Close()
+		r = f
+		uncompressed = c.trimSuffix(name)
+	}
+	cr := &countReader{r: r}
+	br := bufio.NewReader(cr)
+	// only the header is read by compress/gzip
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if _, err = io.Copy(io.Discard, br); err != nil {
+		return 0, 0, "", err
+	}
+	if (c.name && zr.Name != "") || uncompressed == "" {
+		uncompressed = zr.Name
+	}
+	return cr.n, int64(binary.LittleEndian.Uint32(cr.tail[:])), uncompressed, nil
+}
+
+// ratio returns the space saving in percentage, like the gzip command.
+func ratio(compressed, uncompressed int64) float64 {
+	if uncompressed == 0 {
+		return 0
+	}
+	return 100 * (1 - float64(compressed)/float64(uncompressed))
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// countReader counts the bytes read and keeps the last 4 bytes (the ISIZE of the gzip trailer).
+type countReader struct {
+	r    io.Reader
+	n    int64
+	tail [4]byte
+}
+
+func (r *countReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.n += int64(n)
+	if n >= len(r.tail) {
+		copy(r.tail[:], p[n-len(r.tail):n])
+	} else {
+		copy(r.tail[:], r.tail[n:])
+		copy(r.tail[len(r.tail)-n:], p[:n])
+	}
+	return n, err
+}
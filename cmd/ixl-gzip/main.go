@@ -0,0 +1,230 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Command ixl-gzip compresses and decompresses files in gzip format using IAA.
+//
+// It accepts the main flags of gzip, so it can be used in shell scripts in place of gzip:
+//
+//	ixl-gzip [flags] [file ...]
+//
+// Without any file (or with "-"), the standard input is compressed into the standard output.
+// The files compressed by other compressors may use a history window larger than the 4KB supported by IAA,
+// they are decompressed again by compress/gzip if IAA fails,
+// the compressed data read from the standard input is kept in memory to be read again.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/intel/ixl-go/compress"
+)
+
+const usage = `Usage: ixl-gzip [flags] [file ...]
+Compress or decompress files in gzip format using IAA.
+
+  -c, --stdout       write on standard output, keep original files unchanged
+  -d, --decompress   decompress
+  -f, --force        force overwrite of output file and compress links or data to a terminal
+  -k, --keep         keep (don't delete) input files
+  -l, --list         list compressed file contents
+  -n, --no-name      do not save or restore the original name and timestamp
+  -N, --name         save or restore the original name and timestamp
+  -q, --quiet        suppress all warnings
+  -S, --suffix=SUF   use suffix SUF on compressed files (default .gz)
+  -t, --test         test compressed file integrity
+  -v, --verbose      verbose mode
+  -1, --fast         compress faster
+  -9, --best         compress better
+      --parallel     compress the chunks of a file on multiple work queues concurrently (not with --software)
+      --software     use compress/gzip instead of IAA
+
+With no file, or when file is -, read standard input.
+`
+
+// shortFlags are the boolean flags that can be combined, e.g. "-dc".
+const shortFlags = "cdfklnNqtv123456789"
+
+type config struct {
+	stdout     bool
+	decompress bool
+	force      bool
+	keep       bool
+	list       bool
+	noName     bool
+	name       bool
+	quiet      bool
+	suffix     string
+	test       bool
+	verbose    bool
+	level      int
+	parallel   bool
+	software   bool
+
+	stdin  io.Reader
+	output io.Writer // the standard output
+	log    io.Writer // the standard error
+}
+
+// levelFlag is a boolean flag setting the compression level, like "-1".
+type levelFlag struct {
+	level *int
+	value int
+}
+
+func (l levelFlag) String() string { return "" }
+
+func (l levelFlag) Set(s string) error {
+	ok, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if ok {
+		*l.level = l.value
+	}
+	return nil
+}
+
+func (l levelFlag) IsBoolFlag() bool { return true }
+
+// expandArgs splits the combined short flags like "-dc" into "-d" "-c", the flag package doesn't support them.
+func expandArgs(args []string) []string {
+	expanded := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			// the flag package stops at the first non-flag argument
+			return append(expanded, args[i:]...)
+		}
+		if len(arg) > 2 && arg[1] != '-' && strings.Trim(arg[1:], shortFlags) == "" {
+			for _, c := range arg[1:] {
+				expanded = append(expanded, "-"+string(c))
+			}
+			continue
+		}
+		expanded = append(expanded, arg)
+	}
+	return expanded
+}
+
+// parseArgs parses the command line arguments, it returns the config and the files.
+func parseArgs(args []string, stdin io.Reader, stdout, stderr io.Writer) (*config, []string, error) {
+	c := &config{level: compress.DefaultCompression, stdin: stdin, output: stdout, log: stderr}
+	flags := flag.NewFlagSet("ixl-gzip", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	for _, f := range []struct {
+		p     *bool
+		names []string
+	}{
+		{&c.stdout, []string{"c", "stdout", "to-stdout"}},
+		{&c.decompress, []string{"d", "decompress", "uncompress"}},
+		{&c.force, []string{"f", "force"}},
+		{&c.keep, []string{"k", "keep"}},
+		{&c.list, []string{"l", "list"}},
+		{&c.noName, []string{"n", "no-name"}},
+		{&c.name, []string{"N", "name"}},
+		{&c.quiet, []string{"q", "quiet"}},
+		{&c.test, []string{"t", "test"}},
+		{&c.verbose, []string{"v", "verbose"}},
+		{&c.parallel, []string{"parallel"}},
+		{&c.software, []string{"software"}},
+	} {
+		for _, name := range f.names {
+			flags.BoolVar(f.p, name, false, "")
+		}
+	}
+	flags.StringVar(&c.suffix, "S", ".gz", "")
+	flags.StringVar(&c.suffix, "suffix", ".gz", "")
+	for level := compress.BestSpeed; level <= compress.BestCompression; level++ {
+		flags.Var(levelFlag{&c.level, level}, strconv.Itoa(level), "")
+	}
+	flags.Var(levelFlag{&c.level, compress.BestSpeed}, "fast", "")
+	flags.Var(levelFlag{&c.level, compress.BestCompression}, "best", "")
+
+	if err := flags.Parse(expandArgs(args)); err != nil {
+		return nil, nil, err
+	}
+	if c.suffix == "" {
+		return nil, nil, fmt.Errorf("invalid suffix %q", c.suffix)
+	}
+	if c.parallel && c.software {
+		return nil, nil, fmt.Errorf("--parallel can't be used with --software")
+	}
+	if c.quiet {
+		c.verbose = false
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	return c, files, nil
+}
+
+// Exit statuses, the same as gzip.
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitWarning = 2
+)
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c, files, err := parseArgs(args, stdin, stdout, stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "ixl-gzip:", err)
+		return exitError
+	}
+	if !c.software && !compress.Ready() {
+		fmt.Fprintln(stderr, "ixl-gzip: IAA devices not found, use --software to compress by software")
+		return exitError
+	}
+	if c.list {
+		return c.listFiles(files)
+	}
+	status := exitOK
+	for _, file := range files {
+		var err error
+		switch {
+		case c.decompress || c.test:
+			err = c.decompressFile(file)
+		default:
+			err = c.compressFile(file)
+		}
+		if err == nil {
+			continue
+		}
+		if w, ok := err.(warning); ok {
+			if !c.quiet {
+				fmt.Fprintf(stderr, "ixl-gzip: %s: %s\n", file, w)
+			}
+			if status == exitOK {
+				status = exitWarning
+			}
+			continue
+		}
+		if pe, ok := err.(*fs.PathError); ok {
+			err = pe.Err
+		}
+		fmt.Fprintf(stderr, "ixl-gzip: %s: %v\n", file, err)
+		status = exitError
+	}
+	return status
+}
+
+// warning is reported for the files that are skipped, like the gzip command.
+type warning string
+
+func (w warning) Error() string {
+	return string(w)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestExpandArgs(t *testing.T) {
+	for _, c := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"-dc", "a.gz"}, []string{"-d", "-c", "a.gz"}},
+		{[]string{"-k9", "--software", "a", "-dc"}, []string{"-k", "-9", "--software", "a", "-dc"}},
+		{[]string{"-S.z", "-c"}, []string{"-S.z", "-c"}},
+		{[]string{"--", "-dc"}, []string{"--", "-dc"}},
+		{[]string{"-"}, []string{"-"}},
+	} {
+		if args := expandArgs(c.args); !reflect.DeepEqual(args, c.expected) {
+			t.Fatalf("expected %q for %q, got %q", c.expected, c.args, args)
+		}
+	}
+}
+
+func TestTrimSuffix(t *testing.T) {
+	c := &config{suffix: ".z"}
+	for name, expected := range map[string]string{
+		"a.z":      "a",
+		"a.gz":     "a",
+		"dir/a.gz": "dir/a",
+		"a.tgz":    "a.tar",
+		"a.txt":    "",
+		".gz":      "",
+		"dir/.gz":  "",
+	} {
+		if output := c.trimSuffix(name); output != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, name, output)
+		}
+	}
+}
+
+func TestCountReader(t *testing.T) {
+	data := []byte("0123456789")
+	r := &countReader{r: iotest.OneByteReader(bytes.NewReader(data))}
+	_, err := io.Copy(io.Discard, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.n != int64(len(data)) || string(r.tail[:]) != "6789" {
+		t.Fatalf("unexpected count %d and tail %q", r.n, r.tail)
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, mode := range [][]string{{"--software"}, {}, {"--parallel"}} {
+		if len(mode) == 0 || mode[0] != "--software" {
+			if !compress.Ready() {
+				t.Log("IAA devices not found, skip the mode", mode)
+				continue
+			}
+		}
+		testRun(t, mode)
+	}
+}
+
+func testRun(t *testing.T, mode []string) {
+	dir := t.TempDir()
+	data := []byte(testutil.RandomText(200 * 1024))
+	name := filepath.Join(dir, "data.txt")
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.WriteFile(name, data, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	gz := func(stdin []byte, args ...string) (int, string) {
+		stdout := bytes.NewBuffer(nil)
+		stderr := bytes.NewBuffer(nil)
+		status := run(append(mode[:len(mode):len(mode)], args...), bytes.NewReader(stdin), stdout, stderr)
+		if stderr.Len() != 0 {
+			t.Log(stderr.String())
+		}
+		return status, stdout.String()
+	}
+
+	// compress the file, the name and the modification time are saved
+	if status, _ := gz(nil, "-9", name); status != exitOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatal("the input file is not removed")
+	}
+	compressed, err := os.ReadFile(name + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(zr)
+	if err != nil || !bytes.Equal(output, data) {
+		t.Fatal("the file is not decompressed by compress/gzip", err)
+	}
+	if zr.Name != "data.txt" || !zr.ModTime.Equal(modTime) {
+		t.Fatalf("unexpected header %q %v", zr.Name, zr.ModTime)
+	}
+
+	if status, _ := gz(nil, "-t", name+".gz"); status != exitOK {
+		t.Fatalf("unexpected status %d of test", status)
+	}
+	status, list := gz(nil, "-l", name+".gz")
+	if status != exitOK || !strings.Contains(list, name) {
+		t.Fatalf("unexpected status %d of list: %s", status, list)
+	}
+	status, stdout := gz(nil, "-dc", name+".gz")
+	if status != exitOK || stdout != string(data) {
+		t.Fatalf("unexpected status %d of decompression to stdout", status)
+	}
+
+	// the original name is restored
+	renamed := filepath.Join(dir, "renamed.gz")
+	if err = os.Rename(name+".gz", renamed); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := gz(nil, "-dkN", renamed); status != exitOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	output, err = os.ReadFile(name)
+	if err != nil || !bytes.Equal(output, data) {
+		t.Fatal("the file is not decompressed", err)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(modTime) || fi.Mode().Perm() != 0o640 {
+		t.Fatalf("unexpected file info %v %v", fi.ModTime(), fi.Mode())
+	}
+	if _, err = os.Stat(renamed); err != nil {
+		t.Fatal("the input file is not kept")
+	}
+	// the output exists
+	if status, _ := gz(nil, "-dN", renamed); status != exitWarning {
+		t.Fatalf("unexpected status %d", status)
+	}
+	// unknown suffix
+	if status, _ := gz(nil, "-d", name); status != exitWarning {
+		t.Fatalf("unexpected status %d", status)
+	}
+
+	// stdin to stdout
+	status, stdout = gz(data, "-c")
+	if status != exitOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	status, stdout = gz([]byte(stdout), "-d")
+	if status != exitOK || stdout != string(data) {
+		t.Fatalf("unexpected status %d of decompression from stdin", status)
+	}
+}
+
+func TestRun_ParallelSoftware(t *testing.T) {
+	stderr := bytes.NewBuffer(nil)
+	if status := run([]string{"--parallel", "--software"}, bytes.NewReader(nil), io.Discard, stderr); status != exitError {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if !strings.Contains(stderr.String(), "--parallel") {
+		t.Fatalf("unexpected error %q", stderr.String())
+	}
+}
+
+func TestRun_Fallback(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	// the repeated text is far away than 4KB, compress/gzip uses the 32KB window for it
+	data := bytes.Repeat([]byte(testutil.RandomText(10*1024)), 20)
+	buf := bytes.NewBuffer(nil)
+	zw := gzip.NewWriter(buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	name := filepath.Join(t.TempDir(), "data.txt.gz")
+	if err := os.WriteFile(name, buf.Bytes(), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		stdin io.Reader
+		args  []string
+	}{
+		// the standard input can't be rewound
+		{struct{ io.Reader }{bytes.NewReader(buf.Bytes())}, []string{"-d"}},
+		{nil, []string{"-dc", name}},
+	} {
+		stdout := bytes.NewBuffer(nil)
+		stderr := bytes.NewBuffer(nil)
+		if status := run(c.args, c.stdin, stdout, stderr); status != exitOK {
+			t.Fatalf("unexpected status %d: %s", status, stderr.String())
+		}
+		if !bytes.Equal(stdout.Bytes(), data) {
+			t.Fatalf("unexpected output of %v", c.args)
+		}
+	}
+}
+
+func TestCopyDecompressed(t *testing.T) {
+	data := []byte(testutil.RandomText(10 * 1024))
+	buf := bytes.NewBuffer(nil)
+	zw := gzip.NewWriter(buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	c := &config{}
+
+	// the standard input is replayed by compress/gzip
+	src := &recordReader{r: bytes.NewReader(buf.Bytes())}
+	_, _ = io.Copy(io.Discard, src)
+	out := bytes.NewBuffer(nil)
+	if _, err := c.copyDecompressed(out, io.MultiReader(bytes.NewReader(data[:100]), iotest.ErrReader(errors.ErrCorruptedData)), src); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Fatal("unexpected output")
+	}
+
+	// the limit error is not retried
+	src = &recordReader{r: bytes.NewReader(buf.Bytes())}
+	limit := &errors.LimitError{}
+	if _, err := c.copyDecompressed(io.Discard, iotest.ErrReader(limit), src); err != limit {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+
+	// the standard input is too large to be replayed
+	src = &recordReader{r: bytes.NewReader(make([]byte, maxReplay+1))}
+	_, _ = io.Copy(io.Discard, src)
+	if !src.overflow || src.buf.Len() != 0 {
+		t.Fatal("expected the recorded data to be dropped")
+	}
+	_, err := c.copyDecompressed(io.Discard, iotest.ErrReader(errors.ErrCorruptedData), src)
+	if err == nil || !strings.Contains(err.Error(), "--software") {
+		t.Fatalf("expected error suggesting --software, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"hash/crc32"
+
+	"github.com/intel/ixl-go/internal/iaa"
+)
+
+// AdaptiveMode enable adaptive mode to compress the data.
+//
+// The block type is chosen per block: the cost of the stored block, the fixed huffman block
+// and the dynamic huffman block (including the size of its header) are calculated from the statistics of the block,
+// and the cheapest one is used. So the small or low-entropy blocks don't pay for the dynamic header.
+func AdaptiveMode() Option {
+	return func(opt *option) {
+		opt.mode = modeAdaptive
+	}
+}
+
+// writeAdaptiveBlock compresses the block using the cheapest block type.
+func (d *Deflate) writeAdaptiveBlock(block []byte, last bool) (n int, err error) {
+	aecs := &d.aecs[d.toggle]
+	aecs.Reset()
+	histogram := &aecs.Histogram
+	d.descriptor.Reset()
+	d.completionRecord.Reset()
+
+	err = d.statisticBlock(block, histogram)
+	if err != nil {
+		return 0, err
+	}
+	// keep the frequencies, the histogram will be converted into huffman codes.
+	d.counts = *histogram
+
+	headerBits := d.generateHeader(histogram, &aecs.OutputAccumulatorData, last)
+	dynamicBits := headerBits - int(d.bitsNum) + encodedBits(&d.counts, histogram)
+	fixedBits := 3 + encodedBits(&d.counts, &fixedHistogram)
+	// the header of stored block is byte aligned
+	storedBits := 3 + 7 + (storedSize(len(block))-1)*8
+
+	switch {
+	case storedBits <= dynamicBits && storedBits <= fixedBits:
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, block)
+		return len(block), d.writeStoredBlock(block, last)
+	case fixedBits <= dynamicBits:
+		*histogram = fixedHistogram
+		return d.writeFixedBlock(block, last)
+	}
+	err = d.encodeBlock(aecs, block, last, headerBits)
+	if err != nil {
+		return 0, err
+	}
+	d.toggle ^= 1
+	return len(block), nil
+}
+
+// encodedBits returns the size in bits of the symbols encoded by the huffman codes,
+// the extra bits of lengths and distances are included.
+func encodedBits(counts *iaa.Histogram, codes *iaa.Histogram) (bits int) {
+	for i, c := range counts.LiteralCodes {
+		bits += int(c) * int(codes.LiteralCodes[i]>>15)
+	}
+	for i, c := range counts.DistanceCodes {
+		bits += int(c) * int(codes.DistanceCodes[i]>>15)
+	}
+	for i, n := range lengthExtraBits {
+		bits += int(counts.LiteralCodes[257+i]) * n
+	}
+	for i, n := range distanceExtraBits {
+		bits += int(counts.DistanceCodes[i]) * n
+	}
+	return bits
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestEncodedBits(t *testing.T) {
+	var counts iaa.Histogram
+	counts.LiteralCodes['a'] = 10 // 8 bits
+	counts.LiteralCodes[200] = 1  // 9 bits
+	counts.LiteralCodes[256] = 1  // 7 bits
+	counts.LiteralCodes[284] = 1  // 8 bits + 5 extra bits
+	counts.DistanceCodes[29] = 1  // 5 bits + 13 extra bits
+	expected := 80 + 9 + 7 + 8 + 5 + 5 + 13
+	if bits := encodedBits(&counts, &fixedHistogram); bits != expected {
+		t.Fatalf("expected %d bits, got %d", expected, bits)
+	}
+}
+
+func TestAdaptiveMode(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	random := make([]byte, 1024)
+	_, _ = rand.Read(random)
+	tests := []struct {
+		name  string
+		input []byte
+		btype byte
+	}{
+		{"short", []byte("hello hello hello"), 0b01},
+		{"random", random, 0b00},
+		{"text", []byte(testutil.RandomText(defaultBlockSize)), 0b10},
+	}
+	d, err := NewDeflate(nil, AdaptiveMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := d.CompressAll(nil, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if btype := compressed[0] >> 1 & 0b11; btype != tt.btype {
+				t.Fatalf("expected block type %b, got %b", tt.btype, btype)
+			}
+			data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, tt.input) {
+				t.Fatal("decompressed data is not consistent with input")
+			}
+		})
+	}
+
+	// mixed blocks in one stream
+	input := append(append([]byte(testutil.RandomText(100*1024)), random...), "hello"...)
+	buf := bytes.NewBuffer(nil)
+	d.Reset(buf)
+	_, err = d.ReadFrom(bytes.NewReader(input))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(flate.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package bgzf implements the BGZF (blocked gzip) format using IAA.
+//
+// BGZF is the format used by BAM/VCF files: the data is split into blocks of at most 64KB,
+// every block is compressed as an independent gzip member with a "BC" extra field recording the block size.
+// A position in the file is addressed by a virtual offset, which is composed of
+// the offset of the compressed block and the offset in the uncompressed block.
+//
+// Specification: https://samtools.github.io/hts-specs/SAMv1.pdf (section 4.1)
+package bgzf
+
+// BlockSize is the max size of the uncompressed data in one BGZF block.
+const BlockSize = 0xff00
+
+// maxBlockSize is the max size of a compressed BGZF block.
+const maxBlockSize = 0x10000
+
+// headerSize is the size of the BGZF block header.
+const headerSize = 18
+
+// trailerSize is the size of the gzip trailer (CRC32|ISIZE).
+const trailerSize = 8
+
+// eofBlock is the empty block marking the end of a BGZF file.
+var eofBlock = [28]byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0xff, 0x06, 0x00, 0x42, 0x43, 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+// bcExtra is the extra field of the BGZF block, the BSIZE is filled when the block is compressed.
+var bcExtra = []byte{'B', 'C', 2, 0, 0, 0}
+
+// VirtualOffset is the virtual file offset of BGZF:
+// the offset of the compressed block in the file is stored in the upper 48 bits,
+// the offset in the uncompressed block is stored in the lower 16 bits.
+type VirtualOffset uint64
+
+// NewVirtualOffset creates a VirtualOffset.
+func NewVirtualOffset(blockOffset int64, dataOffset int) VirtualOffset {
+	return VirtualOffset(uint64(blockOffset)<<16 | uint64(dataOffset&0xffff))
+}
+
+// BlockOffset returns the offset of the compressed block in the file.
+func (v VirtualOffset) BlockOffset() int64 {
+	return int64(v >> 16)
+}
+
+// DataOffset returns the offset in the uncompressed block.
+func (v VirtualOffset) DataOffset() int {
+	return int(v & 0xffff)
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+// softBGZF compresses the data into BGZF blocks using compress/gzip.
+func softBGZF(t *testing.T, data []byte, blockSize int) ([]byte, *Index) {
+	buf := bytes.NewBuffer(nil)
+	index := &Index{}
+	for i := 0; i < len(data); i += blockSize {
+		end := i + blockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if i != 0 {
+			index.entries = append(index.entries, IndexEntry{Compressed: int64(buf.Len()), Uncompressed: int64(i)})
+		}
+		block := bytes.NewBuffer(nil)
+		w, _ := gzip.NewWriterLevel(block, gzip.BestSpeed)
+		w.Extra = bcExtra
+		w.OS = 255
+		_, _ = w.Write(data[i:end])
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		b := block.Bytes()
+		binary.LittleEndian.PutUint16(b[16:18], uint16(len(b)-1))
+		buf.Write(b)
+	}
+	buf.Write(eofBlock[:])
+	return buf.Bytes(), index
+}
+
+func TestVirtualOffset(t *testing.T) {
+	v := NewVirtualOffset(123456789, 4321)
+	if v.BlockOffset() != 123456789 || v.DataOffset() != 4321 {
+		t.Fatalf("unexpected virtual offset %d %d", v.BlockOffset(), v.DataOffset())
+	}
+}
+
+func TestIndex(t *testing.T) {
+	index := &Index{entries: []IndexEntry{{100, 1000}, {200, 2000}}}
+	buf := bytes.NewBuffer(nil)
+	_, err := index.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 8+2*16 {
+		t.Fatalf("unexpected index size %d", buf.Len())
+	}
+	decoded, err := ReadIndex(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index, decoded) {
+		t.Fatalf("expected %v, got %v", index, decoded)
+	}
+	for _, c := range []struct {
+		offset int64
+		v      VirtualOffset
+	}{
+		{0, NewVirtualOffset(0, 0)},
+		{999, NewVirtualOffset(0, 999)},
+		{1000, NewVirtualOffset(100, 0)},
+		{2500, NewVirtualOffset(200, 500)},
+	} {
+		if v := index.Locate(c.offset); v != c.v {
+			t.Fatalf("expected %x for offset %d, got %x", c.v, c.offset, v)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := []byte(testutil.RandomText(300 * 1024))
+	file, index := softBGZF(t, data, 50000)
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, data) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	for _, offset := range []int64{0, 1, 49999, 50000, 123456, int64(len(data) - 1)} {
+		err = r.SeekUncompressed(offset, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := r.Offset()
+		p := make([]byte, 100)
+		n, err := io.ReadFull(r, p)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(p[:n], data[offset:offset+int64(n)]) {
+			t.Fatalf("unexpected data at offset %d", offset)
+		}
+		// seek by the virtual offset
+		err = r.Seek(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ = io.ReadFull(r, p)
+		if !bytes.Equal(p[:n], data[offset:offset+int64(n)]) {
+			t.Fatalf("unexpected data at virtual offset %x", v)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	data := []byte(testutil.RandomText(500 * 1024))
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offsets []VirtualOffset
+	for i := 0; i < len(data); i += 10000 {
+		offsets = append(offsets, w.Offset())
+		end := i + 10000
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err = w.Write(data[i:end])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+	if !bytes.HasSuffix(file, eofBlock[:]) {
+		t.Fatal("the EOF marker block is not found")
+	}
+
+	// readable by compress/gzip (multistream)
+	gr, err := gzip.NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, data) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range offsets {
+		err = r.Seek(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := make([]byte, 10)
+		_, err = io.ReadFull(r, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(p, data[i*10000:i*10000+10]) {
+			t.Fatalf("unexpected data at virtual offset %x", v)
+		}
+	}
+	index := w.Index()
+	if len(index.Entries()) != len(data)/BlockSize {
+		t.Fatalf("unexpected index entries: %d", len(index.Entries()))
+	}
+	err = r.SeekUncompressed(300000, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 10)
+	_, err = io.ReadFull(r, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p, data[300000:300010]) {
+		t.Fatal("unexpected data at uncompressed offset 300000")
+	}
+}
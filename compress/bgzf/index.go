@@ -0,0 +1,89 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package bgzf
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+// IndexEntry is the position of a block.
+type IndexEntry struct {
+	Compressed   int64 // offset of the compressed block in the file
+	Uncompressed int64 // offset of the uncompressed data of the block
+}
+
+// Index is the block index of a BGZF file, which is stored as a .gzi file.
+//
+// The first block (at offset 0) is not included, which is same as the .gzi format of htslib.
+type Index struct {
+	entries []IndexEntry
+}
+
+// Entries returns the entries of the index.
+func (x *Index) Entries() []IndexEntry {
+	return x.entries
+}
+
+// Locate returns the virtual offset of the uncompressed offset.
+func (x *Index) Locate(offset int64) VirtualOffset {
+	e := x.find(offset)
+	return NewVirtualOffset(e.Compressed, int(offset-e.Uncompressed))
+}
+
+// find returns the last block starts before the offset.
+func (x *Index) find(offset int64) IndexEntry {
+	n := sort.Search(len(x.entries), func(i int) bool {
+		return x.entries[i].Uncompressed > offset
+	})
+	if n == 0 {
+		return IndexEntry{}
+	}
+	return x.entries[n-1]
+}
+
+// ReadIndex reads a .gzi index.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var buf [16]byte
+	_, err := io.ReadFull(r, buf[:8])
+	if err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint64(buf[:8])
+	x := &Index{}
+	for i := uint64(0); i < n; i++ {
+		_, err = io.ReadFull(r, buf[:])
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		x.entries = append(x.entries, IndexEntry{
+			Compressed:   int64(binary.LittleEndian.Uint64(buf[:8])),
+			Uncompressed: int64(binary.LittleEndian.Uint64(buf[8:])),
+		})
+	}
+	for i := 1; i < len(x.entries); i++ {
+		if x.entries[i].Uncompressed < x.entries[i-1].Uncompressed {
+			return nil, errors.InvalidArgument
+		}
+	}
+	return x, nil
+}
+
+// WriteTo writes the index in .gzi format.
+func (x *Index) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, 8+16*len(x.entries))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(x.entries)))
+	for _, e := range x.entries {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(e.Compressed))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(e.Uncompressed))
+	}
+	n, err := w.Write(buf)
+	return int64(n), err
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// Reader decompresses BGZF blocks.
+//
+// The blocks are decompressed by IAA, every block is decompressed by one job.
+// The software decompressor (compress/flate) is used when no device is found or the job fails.
+type Reader struct {
+	r       io.Reader
+	inflate *compress.Inflate
+	soft    io.ReadCloser
+
+	header []byte
+	input  []byte // the compressed block
+	block  []byte // the uncompressed data of the current block
+	pos    int    // the read position in the block
+
+	offset int64 // the offset of the current block
+	next   int64 // the offset of the next block
+	err    error
+}
+
+// NewReader creates a new Reader reading BGZF blocks from `r`.
+// The `r` must implement io.Seeker to use the Seek methods.
+func NewReader(r io.Reader, opts ...compress.Option) (*Reader, error) {
+	br := &Reader{
+		r:      r,
+		header: make([]byte, 12),
+		input:  mem.Alloc64ByteAligned(maxBlockSize),
+		block:  mem.Alloc64ByteAligned(maxBlockSize)[:0],
+	}
+	if compress.Ready() {
+		var err error
+		br.inflate, err = compress.NewInflate(nil, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return br, nil
+}
+
+// Read reads the uncompressed data.
+func (r *Reader) Read(data []byte) (n int, err error) {
+	for r.pos == len(r.block) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.readBlock()
+	}
+	n = copy(data, r.block[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// readBlock reads and decompresses the next block.
+func (r *Reader) readBlock() error {
+	r.offset = r.next
+	r.block = r.block[:0]
+	r.pos = 0
+	_, err := io.ReadFull(r.r, r.header)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.ErrBGZFHeader
+		}
+		return err
+	}
+	h := r.header
+	if h[0] != 0x1f || h[1] != 0x8b || h[2] != 8 || h[3]&4 == 0 {
+		return errors.ErrBGZFHeader
+	}
+	xlen := int(binary.LittleEndian.Uint16(h[10:12]))
+	extra := r.input[:xlen]
+	_, err = io.ReadFull(r.r, extra)
+	if err != nil {
+		return noEOF(err)
+	}
+	size := -1
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if extra[0] == 'B' && extra[1] == 'C' && slen == 2 && len(extra) >= 6 {
+			size = int(binary.LittleEndian.Uint16(extra[4:6])) + 1
+			break
+		}
+		if len(extra) < 4+slen {
+			break
+		}
+		extra = extra[4+slen:]
+	}
+	remain := size - len(r.header) - xlen
+	if size < 0 || remain < trailerSize {
+		return errors.ErrBGZFHeader
+	}
+	input := r.input[:remain]
+	_, err = io.ReadFull(r.r, input)
+	if err != nil {
+		return noEOF(err)
+	}
+	r.next = r.offset + int64(size)
+
+	compressed := input[:remain-trailerSize]
+	crc := binary.LittleEndian.Uint32(input[remain-trailerSize:])
+	isize := int(binary.LittleEndian.Uint32(input[remain-4:]))
+	if isize > maxBlockSize {
+		return errors.ErrBGZFHeader
+	}
+	r.block = r.block[:isize]
+	if isize == 0 {
+		return nil
+	}
+	if r.inflate == nil || r.decompress(compressed) != nil {
+		err = r.softDecompress(compressed)
+		if err != nil {
+			return err
+		}
+	}
+	if crc32.ChecksumIEEE(r.block) != crc {
+		return errors.ErrChecksum
+	}
+	return nil
+}
+
+func (r *Reader) decompress(compressed []byte) error {
+	n, err := r.inflate.DecompressAll(compressed, r.block)
+	if err == nil && n != len(r.block) {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (r *Reader) softDecompress(compressed []byte) (err error) {
+	if r.soft == nil {
+		r.soft = flate.NewReader(bytes.NewReader(compressed))
+	} else {
+		err = r.soft.(flate.Resetter).Reset(bytes.NewReader(compressed), nil)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.ReadFull(r.soft, r.block)
+	return err
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Offset returns the virtual offset of the next byte to read.
+func (r *Reader) Offset() VirtualOffset {
+	if r.pos == len(r.block) {
+		return NewVirtualOffset(r.next, 0)
+	}
+	return NewVirtualOffset(r.offset, r.pos)
+}
+
+// Seek moves to the virtual offset, the underlying reader must implement io.Seeker.
+func (r *Reader) Seek(offset VirtualOffset) error {
+	seeker, ok := r.r.(io.Seeker)
+	if !ok {
+		return errors.InvalidArgument
+	}
+	_, err := seeker.Seek(offset.BlockOffset(), io.SeekStart)
+	if err != nil {
+		return err
+	}
+	r.err = nil
+	r.next = offset.BlockOffset()
+	r.block = r.block[:0]
+	r.pos = 0
+	if offset.DataOffset() == 0 {
+		return nil
+	}
+	err = r.readBlock()
+	if err != nil {
+		r.err = err
+		return err
+	}
+	if offset.DataOffset() > len(r.block) {
+		return errors.InvalidArgument
+	}
+	r.pos = offset.DataOffset()
+	return nil
+}
+
+// SeekUncompressed moves to the uncompressed offset using the .gzi index.
+func (r *Reader) SeekUncompressed(offset int64, index *Index) error {
+	e := index.find(offset)
+	err := r.Seek(NewVirtualOffset(e.Compressed, 0))
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(io.Discard, r, offset-e.Uncompressed)
+	return err
+}
+
+// Reset discards the state and makes the Reader reading from `r`.
+func (r *Reader) Reset(reader io.Reader) {
+	r.r = reader
+	r.block = r.block[:0]
+	r.pos = 0
+	r.offset = 0
+	r.next = 0
+	r.err = nil
+}
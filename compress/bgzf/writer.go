@@ -0,0 +1,134 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package bgzf
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+// Writer compresses the written data into BGZF blocks using IAA.
+//
+// Notice: Close writes the EOF marker block, but it does not close the underlying writer.
+type Writer struct {
+	w      io.Writer
+	gzip   *compress.Gzip
+	block  []byte // the uncompressed data of the current block
+	output []byte
+	offset int64 // the compressed bytes written
+	sum    int64 // the uncompressed bytes written
+	index  *Index
+	closed bool
+	err    error
+}
+
+// NewWriter creates a new Writer writing BGZF blocks to `w`.
+func NewWriter(w io.Writer, opts ...compress.Option) (*Writer, error) {
+	if !compress.Ready() {
+		return nil, errors.NoHardwareDeviceDetected
+	}
+	bw := &Writer{
+		w:      w,
+		gzip:   compress.NewGzip(nil, opts...),
+		block:  make([]byte, 0, BlockSize),
+		output: make([]byte, 0, maxBlockSize),
+		index:  &Index{},
+	}
+	bw.gzip.Extra = bcExtra
+	return bw, nil
+}
+
+// Write writes the data into blocks, a block is compressed when it's full.
+func (w *Writer) Write(data []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, errors.ErrWriterClosed
+	}
+	for len(data) > 0 {
+		size := copy(w.block[len(w.block):BlockSize], data)
+		w.block = w.block[:len(w.block)+size]
+		n += size
+		data = data[size:]
+		if len(w.block) == BlockSize {
+			if err = w.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush compresses the data of the current block and writes the block into the underlying writer,
+// the next written data starts a new block.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.block) == 0 {
+		return nil
+	}
+	if w.offset != 0 {
+		w.index.entries = append(w.index.entries, IndexEntry{
+			Compressed:   w.offset,
+			Uncompressed: w.sum,
+		})
+	}
+	w.output, w.err = w.gzip.CompressAll(w.output[:0], w.block)
+	if w.err != nil {
+		return w.err
+	}
+	// BSIZE: total block size minus 1
+	binary.LittleEndian.PutUint16(w.output[16:18], uint16(len(w.output)-1))
+	_, w.err = w.w.Write(w.output)
+	if w.err != nil {
+		return w.err
+	}
+	w.offset += int64(len(w.output))
+	w.sum += int64(len(w.block))
+	w.block = w.block[:0]
+	return nil
+}
+
+// Offset returns the virtual offset of the next written byte.
+func (w *Writer) Offset() VirtualOffset {
+	return NewVirtualOffset(w.offset, len(w.block))
+}
+
+// Index returns the index (.gzi) of the blocks written since the last Reset.
+func (w *Writer) Index() *Index {
+	return w.index
+}
+
+// Close flushes the current block and writes the EOF marker block.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, w.err = w.w.Write(eofBlock[:])
+	if w.err == nil {
+		w.offset += int64(len(eofBlock))
+	}
+	return w.err
+}
+
+// Reset discards the state and makes the Writer writing into `w`.
+func (w *Writer) Reset(writer io.Writer) {
+	w.w = writer
+	w.block = w.block[:0]
+	w.offset = 0
+	w.sum = 0
+	w.index = &Index{}
+	w.closed = false
+	w.err = nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import "hash/crc32"
+
+// crc32Combine returns the CRC-32 (IEEE) of the concatenation of two data,
+// crc1 is the checksum of the first data and crc2 is the checksum of the second data with length len2.
+//
+// The algorithm is the same as crc32_combine in zlib:
+// appending len2 zero bytes to the first data is a linear operation in GF(2),
+// so it can be done by multiplying the operator matrix log(len2) times.
+func crc32Combine(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1
+	}
+	var even, odd [32]uint32
+
+	// operator for one zero bit
+	odd[0] = crc32.IEEE
+	row := uint32(1)
+	for n := 1; n < 32; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	// operator for two zero bits
+	gf2MatrixSquare(&even, &odd)
+	// operator for four zero bits
+	gf2MatrixSquare(&odd, &even)
+
+	// apply len2 zeros to crc1 (first square will put the operator for one zero byte into even)
+	for {
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat *[32]uint32, vec uint32) (sum uint32) {
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat *[32]uint32) {
+	for n := 0; n < 32; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
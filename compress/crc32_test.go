@@ -0,0 +1,23 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestCRC32Combine(t *testing.T) {
+	data := []byte(testutil.RandomText(64 * 1024))
+	for _, split := range []int{0, 1, 7, 100, 4096, 32*1024 + 3, len(data)} {
+		crc1 := crc32.ChecksumIEEE(data[:split])
+		crc2 := crc32.ChecksumIEEE(data[split:])
+		got := crc32Combine(crc1, crc2, int64(len(data)-split))
+		if want := crc32.ChecksumIEEE(data); got != want {
+			t.Fatalf("split %d: expected crc %x, got %x", split, want, got)
+		}
+	}
+}
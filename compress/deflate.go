@@ -4,7 +4,9 @@
 package compress
 
 import (
+	"compress/flate"
 	"encoding/binary"
+	"hash/crc32"
 	"io"
 	"runtime"
 	"unsafe"
@@ -17,8 +19,35 @@ import (
 )
 
 type option struct {
-	mode     deflateMode
-	busyPoll bool // busyPoll or goroutine schedule
+	mode        deflateMode
+	level       int
+	busyPoll    bool  // busyPoll or goroutine schedule
+	concurrency int   // max number of chunks compressed concurrently (ParallelGzip)
+	chunkSize   int   // size of the chunk compressed by one goroutine (ParallelGzip)
+	blockSize   int   // max size of the blocks
+	err         error // invalid option
+
+	table            *HuffmanTable // canned huffman table
+	reuseTable       bool
+	rebuildThreshold float64
+
+	verify       bool
+	packageMerge bool // generate the huffman codes by the package-merge algorithm
+	pipeline     bool // keep the encode job in flight while the next block is analyzed
+
+	limits limits // decompression limits
+}
+
+func newOption(opts []Option) *option {
+	opt := &option{level: DefaultCompression, blockSize: defaultBlockSize}
+	for _, optf := range opts {
+		optf(opt)
+		if opt.err != nil {
+			// the first error is kept
+			break
+		}
+	}
+	return opt
 }
 
 type deflateMode uint8
@@ -27,6 +56,8 @@ const (
 	modeDynamic deflateMode = iota
 	modeFixed
 	modeHuffmanOnly
+	modeStored
+	modeAdaptive
 )
 
 // Deflate takes data written to it and writes the deflate compressed
@@ -37,11 +68,13 @@ const (
 //  1. the history buffer used by hardware is 4KB.
 //  2. the `Deflate` object should be reused as much as possible to reduce the GC overhead.
 type Deflate struct {
-	w   io.Writer
-	ctx *device.Context
+	w        io.Writer
+	appender appendWriter // used by CompressAll
+	ctx      *device.Context
 
-	mode     deflateMode
-	busyPoll bool
+	mode      deflateMode
+	busyPoll  bool
+	blockSize int // max size of the blocks
 
 	output []byte
 
@@ -53,6 +86,35 @@ type Deflate struct {
 	codeGen         huffman.TreeGenerator
 	dynHeader       *dynamicHeader
 
+	soft *flate.Writer // software compressor, used for preset dictionary
+
+	estimateHist *iaa.Histogram // used by Estimate and buildTable
+	counts       iaa.Histogram  // the symbol frequencies of the block, used by adaptive mode
+
+	table            *HuffmanTable // the huffman table reused across blocks
+	cannedTable      *HuffmanTable
+	ownTable         *HuffmanTable // the table built by this Deflate
+	reuseTable       bool
+	tableExpired     bool
+	tableRatio       float64 // the ratio of the first block encoded by the table
+	rebuildThreshold float64
+	encodedSize      int // the size of the last encoded block
+
+	stream blockBuffer
+
+	verifier       *Inflate // decompressor used to verify the blocks
+	verifyInput    bitAppender
+	verifyOutput   []byte
+	verifyFailures int64
+	verifyHook     func(output []byte) // called with the compressed block before verifying it, used by tests
+
+	pipeline     bool
+	stats        *pipelineStats // the statistic job of the pipelined mode
+	pending      device.Job     // the encode job in flight
+	pendingBlock []byte         // the block of the encode job in flight, nil if there is no job in flight
+	pendingLast  bool
+	pendingCRC   uint32 // the CRC before the block of the encode job in flight
+
 	descriptor       iaa.Descriptor
 	completionRecord *iaa.CompletionRecord
 	aecs             *compressAECSPair
@@ -69,6 +131,13 @@ type iaaCachedObject struct {
 
 type compressAECSPair [2]iaa.CompressAECS
 
+// pipelineStats holds the statistic job of the pipelined mode,
+// it's separated from the AECS pair because the encode job in flight writes its state into the AECS pair.
+type pipelineStats struct {
+	record    iaa.CompletionRecord
+	histogram iaa.Histogram
+}
+
 // Option type is used to configure how the library handles your compression or decompression.
 type Option func(opt *option)
 
@@ -101,6 +170,62 @@ func BusyPoll() Option {
 	}
 }
 
+// PackageMerge makes the Deflate generate the huffman codes of the dynamic blocks by the package-merge algorithm.
+// The code lengths are optimal under the length limits of deflate (15 bits, and 7 bits for the code length codes),
+// instead of being repaired heuristically, so the blocks of skewed data are smaller at the cost of more CPU time.
+func PackageMerge() Option {
+	return func(opt *option) {
+		opt.packageMerge = true
+	}
+}
+
+// BlockSize sets the max size of the deflate blocks, the default size is 32KB.
+// The size must not be smaller than 32KB, and must not be larger than the device's max transfer size.
+//
+// Every block is compressed by two jobs and pays for its own dynamic header,
+// so larger blocks cut the overhead on large payloads, at the cost of larger buffers.
+// The huffman codes are generated from the histogram of the whole block.
+func BlockSize(size int) Option {
+	return func(opt *option) {
+		if size < defaultBlockSize {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.blockSize = size
+	}
+}
+
+// Pipeline makes the Deflate keep the encode job of a block in flight while the next block is analyzed,
+// the statistic job of the next block runs on the device and its huffman codes are generated by the CPU
+// while the previous block is being encoded, which improves the throughput of a single stream.
+//
+// Only the dynamic mode and the huffman only mode can be pipelined,
+// and it can't be used with CannedTable, or ReuseTable.
+//
+// The compressed data of a block is written to the underlying writer by the next write,
+// or by Flush and Close.
+func Pipeline() Option {
+	return func(opt *option) {
+		opt.pipeline = true
+	}
+}
+
+// pipelineSupported reports whether the blocks compressed with the options can be pipelined.
+func (opt *option) pipelineSupported() bool {
+	if opt.mode != modeDynamic && opt.mode != modeHuffmanOnly {
+		return false
+	}
+	return opt.table == nil && !opt.reuseTable
+}
+
+// newTreeGenerator creates the huffman tree generator selected by the options.
+func newTreeGenerator(opt *option) huffman.TreeGenerator {
+	if opt.packageMerge {
+		return huffman.NewPackageMergeCode()
+	}
+	return huffman.NewLenLimitedCode()
+}
+
 // NewDeflate returns a new Deflate writing compressed data to underlying writer `w`.
 func NewDeflate(w io.Writer, opts ...Option) (*Deflate, error) {
 	ctx := iaa.LoadContext()
@@ -109,29 +234,52 @@ func NewDeflate(w io.Writer, opts ...Option) (*Deflate, error) {
 		// no device found
 		return nil, errors.NoHardwareDeviceDetected
 	}
-	opt := &option{}
-	for _, optf := range opts {
-		optf(opt)
+	opt := newOption(opts)
+	if opt.err != nil {
+		return nil, opt.err
+	}
+	if opt.pipeline && !opt.pipelineSupported() {
+		return nil, errors.InvalidArgument
+	}
+	if opt.blockSize > int(ctx.MaxTransferSize()) {
+		return nil, errors.DataSizeTooLarge
 	}
 
 	deflate := &Deflate{
-		ctx:      ctx,
-		busyPoll: opt.busyPoll,
-		mode:     opt.mode,
-		w:        w,
-		// size(block) + storedBlockHeaderSize + lastBlockBits
-		output: mem.Alloc64ByteAligned(maxBlockSize + 5 + 1),
+		ctx:       ctx,
+		busyPoll:  opt.busyPoll,
+		mode:      opt.mode,
+		blockSize: opt.blockSize,
+		w:         w,
+		// size(stored blocks) + lastBlockBits
+		output: mem.Alloc64ByteAligned(uintptr(storedSize(opt.blockSize) + 1)),
+
+		cannedTable:      opt.table,
+		reuseTable:       opt.reuseTable,
+		rebuildThreshold: opt.rebuildThreshold,
+	}
+	deflate.table = opt.table
+	deflate.stream.blockSize = opt.blockSize
+	deflate.stream.pipeline = opt.pipeline
+	if opt.pipeline {
+		deflate.pipeline = true
+		deflate.stats = mem.Alloc64Align[pipelineStats]()
+	}
+	if opt.verify {
+		if err := deflate.newVerifier(); err != nil {
+			return nil, err
+		}
 	}
 	ico := mem.Alloc64Align[iaaCachedObject]()
 	deflate.completionRecord = &ico.CompletionRecord
 	deflate.aecs = &ico.compressAECSPair
 	switch opt.mode {
-	case modeDynamic, modeHuffmanOnly:
-		deflate.dynHeader = newDynamicHeader()
+	case modeDynamic, modeHuffmanOnly, modeAdaptive:
 		deflate.frame = headerFrame{}
-		deflate.litGen = huffman.NewLenLimitedCode()
-		deflate.offsetGen = huffman.NewLenLimitedCode()
-		deflate.codeGen = huffman.NewLenLimitedCode()
+		deflate.litGen = newTreeGenerator(opt)
+		deflate.offsetGen = newTreeGenerator(opt)
+		deflate.codeGen = newTreeGenerator(opt)
+		deflate.dynHeader = newDynamicHeader(deflate.codeGen)
 		deflate.descriptor = iaa.Descriptor{}
 		deflate.cacheForGencode = make([]int32, 16*2)
 	case modeFixed:
@@ -139,29 +287,72 @@ func NewDeflate(w io.Writer, opts ...Option) (*Deflate, error) {
 		copy(deflate.aecs[1].Histogram.DistanceCodes[:], fixedHistogram.DistanceCodes[:])
 		copy(deflate.aecs[0].Histogram.LiteralCodes[:], fixedHistogram.LiteralCodes[:])
 		copy(deflate.aecs[1].Histogram.LiteralCodes[:], fixedHistogram.LiteralCodes[:])
+	case modeStored:
 	}
 
 	return deflate, nil
 }
 
+func (d *Deflate) bufferSize() int {
+	return d.blockSize
+}
+
+func (d *Deflate) pipelined() bool {
+	return d.pipeline
+}
+
+func (d *Deflate) writer() io.Writer {
+	return d.w
+}
+
 // Reset the `Deflate` object.
 func (d *Deflate) Reset(w io.Writer) {
+	d.discardPending()
+	d.toggle = 0
 	d.crc = 0
 	d.bits = 0
 	d.bitsNum = 0
 	d.w = w
+	d.verifyFailures = 0
+	d.stream.reset()
+	d.resetTable()
+	if d.soft != nil {
+		d.soft.Reset(w)
+	}
 }
 
-// maxBlockSize is max deflate block size.
-const maxBlockSize = 32 * 1024
+// defaultBlockSize is the default max deflate block size, see BlockSize.
+const defaultBlockSize = 32 * 1024
+
+// storedBlockHeaderSize is the size of |BFINAL+BTYPE|LEN|NLEN| for a byte aligned stored block.
+const storedBlockHeaderSize = 5
+
+// maxStoredBlockSize is the max size of the data of a stored block, the LEN field is 16 bits.
+const maxStoredBlockSize = 0xffff
+
+// storedSize returns the size of the data written as byte aligned stored blocks,
+// the data larger than maxStoredBlockSize is split into multiple stored blocks.
+func storedSize(size int) int {
+	blocks := (size + maxStoredBlockSize - 1) / maxStoredBlockSize
+	if blocks == 0 {
+		blocks = 1
+	}
+	return size + blocks*storedBlockHeaderSize
+}
 
 // ReadFrom reads all data from `r` and compresses the data and then writes compressed data into underlying writer `w`.
 func (d *Deflate) ReadFrom(r io.Reader) (total int64, err error) {
+	buffers := 2
+	if d.pipeline {
+		// the third buffer keeps the block in flight
+		buffers = 3
+	}
 	if d.readcache == nil {
-		d.readcache = make([]byte, maxBlockSize*2)
+		d.readcache = make([]byte, d.blockSize*buffers)
 	}
 
-	current, prev := d.readcache[:maxBlockSize], d.readcache[maxBlockSize:]
+	current, prev := d.readcache[:d.blockSize], d.readcache[d.blockSize:2*d.blockSize]
+	spare := d.readcache[2*d.blockSize:]
 
 	prevSize := 0
 	for {
@@ -203,8 +394,13 @@ func (d *Deflate) ReadFrom(r io.Reader) (total int64, err error) {
 		if err != nil {
 			return total, err
 		}
-		// exchange current block with prev block
 		prevSize = size
+		if d.pipeline {
+			// prev block is in flight, read the next block into the spare buffer
+			prev, current, spare = current, spare, prev
+			continue
+		}
+		// exchange current block with prev block
 		prev, current = current, prev
 		continue
 	}
@@ -218,7 +414,22 @@ func (d *Deflate) ReadFrom(r io.Reader) (total int64, err error) {
 //     You can use `mem.Alloc64ByteAligned` function to alloc a 64 bytes aligned bytes.
 //  2. The `last` argument must be true if the block is the last block in the stream.
 //  3. For most scenarios, you should use the `ReadFrom` method.
+//  4. In the pipelined mode, the block must not be modified until the next call of writeBlock returns,
+//     see Pipeline.
 func (d *Deflate) writeBlock(block []byte, last bool) (n int, err error) {
+	if last {
+		d.stream.closed = true
+	}
+	if d.soft != nil {
+		return d.writeSoftBlock(block, last)
+	}
+	if d.pipeline && len(block) != 0 {
+		return d.writePipelinedBlock(block, last)
+	}
+	err = d.finishPending()
+	if err != nil {
+		return 0, err
+	}
 	if len(block) == 0 {
 		err = d.writeStoredBlock(block, last)
 		return 0, err
@@ -226,8 +437,16 @@ func (d *Deflate) writeBlock(block []byte, last bool) (n int, err error) {
 	switch d.mode {
 	case modeFixed:
 		return d.writeFixedBlock(block, last)
+	case modeStored:
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, block)
+		return len(block), d.writeStoredBlock(block, last)
+	case modeAdaptive:
+		return d.writeAdaptiveBlock(block, last)
 	case modeDynamic, modeHuffmanOnly:
 	}
+	if d.table != nil || d.reuseTable {
+		return d.writeTableBlock(block, last)
+	}
 
 	aecs := &d.aecs[d.toggle]
 	aecs.Reset()
@@ -253,6 +472,81 @@ func (d *Deflate) writeBlock(block []byte, last bool) (n int, err error) {
 	return len(block), err
 }
 
+// CompressAll compresses all data in src as a whole deflate stream and appends the result to dst.
+// The src may be larger than the device's max transfer size.
+//
+// CompressAll resets the state of the Deflate, the underlying writer is kept.
+// No memory is allocated if dst has enough capacity, see CompressBound.
+func (d *Deflate) CompressAll(dst, src []byte) ([]byte, error) {
+	dst, _, err := d.compressAll(dst, src)
+	return dst, err
+}
+
+// compressAll is same as CompressAll, but returns the CRC-32 of src too.
+func (d *Deflate) compressAll(dst, src []byte) (_ []byte, crc uint32, err error) {
+	w := d.w
+	d.Reset(&d.appender)
+	d.appender.buf = dst
+	err = d.writeAll(src)
+	dst = d.appender.buf
+	crc = d.crc
+	d.appender.buf = nil
+	d.Reset(w)
+	return dst, crc, err
+}
+
+// writeAll writes all data as blocks, the final block is marked as the last block.
+func (d *Deflate) writeAll(data []byte) (err error) {
+	for len(data) > d.blockSize {
+		_, err = d.writeBlock(data[:d.blockSize], false)
+		if err != nil {
+			return err
+		}
+		data = data[d.blockSize:]
+	}
+	_, err = d.writeBlock(data, true)
+	return err
+}
+
+// CompressBound returns the max size of the compressed deflate stream for the input with `size` bytes.
+// The gzip format needs 18 more bytes (and the bytes of the header fields), the zlib format needs 6 more bytes.
+func CompressBound(size int) int {
+	// in the worst case every block is a stored block,
+	// with 5 bytes header and one byte holding the bits left by the prev block.
+	return size + (size/defaultBlockSize+1)*6
+}
+
+type appendWriter struct {
+	buf []byte
+}
+
+func (a *appendWriter) Write(data []byte) (int, error) {
+	a.buf = append(a.buf, data...)
+	return len(data), nil
+}
+
+// writeChunk compresses the chunk as non-final blocks and then aligns the output to byte boundary
+// by an empty stored block (like the sync flush of zlib),
+// so the compressed chunks can be concatenated into one deflate stream.
+func (d *Deflate) writeChunk(chunk []byte) (err error) {
+	for len(chunk) > 0 {
+		size := len(chunk)
+		if size > d.blockSize {
+			size = d.blockSize
+		}
+		_, err = d.writeBlock(chunk[:size], false)
+		if err != nil {
+			return err
+		}
+		chunk = chunk[size:]
+	}
+	err = d.finishPending()
+	if err != nil {
+		return err
+	}
+	return d.writeStoredBlock(nil, false)
+}
+
 func (d *Deflate) writeFixedBlock(block []byte, last bool) (n int, err error) {
 	aecs := &d.aecs[d.toggle]
 	aecs.ResetKeepHistogram()
@@ -288,9 +582,13 @@ func (d *Deflate) submit() iaa.StatusCode {
 }
 
 func (d *Deflate) statisticBlock(block []byte, histogram *iaa.Histogram) error {
+	if d.pipeline {
+		// the completion record may be used by the encode job in flight (e.g. Estimate between writes)
+		return d.statisticPipelined(block, histogram)
+	}
 	d.descriptor.Reset()
 	d.completionRecord.Reset()
-	d.statsJob(block, histogram)
+	d.statsJob(block, histogram, d.completionRecord)
 	status := d.submit()
 	runtime.KeepAlive(histogram)
 	runtime.KeepAlive(d.completionRecord)
@@ -302,7 +600,7 @@ func (d *Deflate) statisticBlock(block []byte, histogram *iaa.Histogram) error {
 	return nil
 }
 
-func (d *Deflate) statsJob(block []byte, histogram *iaa.Histogram) {
+func (d *Deflate) statsJob(block []byte, histogram *iaa.Histogram, record *iaa.CompletionRecord) {
 	desc := &d.descriptor
 
 	desc.SetOpcode(iaa.OpCompress)
@@ -320,7 +618,7 @@ func (d *Deflate) statsJob(block []byte, histogram *iaa.Histogram) {
 	desc.Size = uint32(len(block))
 	desc.DestAddr = uintptr(unsafe.Pointer(histogram))
 	desc.MaxDestionationSize = uint32(unsafe.Sizeof(iaa.Histogram{}))
-	desc.SetCompleteRecord(uintptr(unsafe.Pointer(d.completionRecord)))
+	desc.SetCompleteRecord(uintptr(unsafe.Pointer(record)))
 }
 
 func (d *Deflate) encodeJob(block []byte, output []byte, aesc *iaa.CompressAECS) {
@@ -355,6 +653,10 @@ func (d *Deflate) encodeJob(block []byte, output []byte, aesc *iaa.CompressAECS)
 
 	desc.DestAddr = uintptr(unsafe.Pointer(&output[0]))
 	desc.MaxDestionationSize = uint32(len(output))
+	if limit := d.ctx.MaxTransferSize(); desc.MaxDestionationSize > limit {
+		// the output is larger than the block, it overflows only if the block is incompressible
+		desc.MaxDestionationSize = limit
+	}
 
 	desc.Src2Addr = uintptr(unsafe.Pointer(aesc))
 	desc.Src2Size = uint32(unsafe.Sizeof(iaa.CompressAECS{}))
@@ -363,6 +665,12 @@ func (d *Deflate) encodeJob(block []byte, output []byte, aesc *iaa.CompressAECS)
 }
 
 func (d *Deflate) generateHeader(histogram *iaa.Histogram, data *[256]byte, last bool) (headerBits int) {
+	d.generateCodes(histogram)
+	return d.writeHeader(histogram, data, last)
+}
+
+// generateCodes converts the symbol frequencies in the histogram into huffman codes.
+func (d *Deflate) generateCodes(histogram *iaa.Histogram) {
 	// generate huffman tree
 	litCodes := histogram.LiteralCodes[:]
 	offsetCodes := histogram.DistanceCodes[:]
@@ -372,7 +680,10 @@ func (d *Deflate) generateHeader(histogram *iaa.Histogram, data *[256]byte, last
 	huffman.GenerateCodeForIAA(litCodes, d.cacheForGencode)
 	d.offsetGen.Generate(15, offsetCodes, offsetCodes)
 	huffman.GenerateCodeForIAA(offsetCodes, d.cacheForGencode)
+}
 
+// writeHeader writes the dynamic block header described by the huffman codes into accumulator data.
+func (d *Deflate) writeHeader(histogram *iaa.Histogram, data *[256]byte, last bool) (headerBits int) {
 	d.frame.start(data)
 
 	if d.bitsNum != 0 {
@@ -384,29 +695,49 @@ func (d *Deflate) generateHeader(histogram *iaa.Histogram, data *[256]byte, last
 }
 
 func (d *Deflate) encodeBlock(aecs *iaa.CompressAECS, block []byte, last bool, headerBits int) (err error) {
+	prev := d.crc
+	d.prepareEncode(aecs, block, headerBits)
+	status := d.submit()
+	return d.encodeResult(status, block, last, prev)
+}
+
+// prepareEncode fills the descriptor of the encode job for the block.
+func (d *Deflate) prepareEncode(aecs *iaa.CompressAECS, block []byte, headerBits int) {
 	d.descriptor.Reset()
 	d.completionRecord.Reset()
 	aecs.NumAccBitsValid = uint32(headerBits)
 	// set prev crc result
 	aecs.CRC = d.crc
 	d.encodeJob(block, d.output, &d.aecs[0])
-	status := d.submit()
+}
+
+// encodeResult handles the result of the encode job, and writes the encoded block to the underlying writer.
+// The `prev` argument is the CRC before the block.
+func (d *Deflate) encodeResult(status iaa.StatusCode, block []byte, last bool, prev uint32) (err error) {
 	if status != iaa.Success {
-		if status == iaa.OutputBufferOverflow {
-			return d.writeStoredBlock(block, last)
-		}
-		if status == iaa.AnalyticsError &&
-			d.completionRecord.GetHeader().ErrorCode == iaa.ErrorCodeUnrecoverableOutputOverflow {
+		if status == iaa.OutputBufferOverflow ||
+			(status == iaa.AnalyticsError &&
+				d.completionRecord.GetHeader().ErrorCode == iaa.ErrorCodeUnrecoverableOutputOverflow) {
+			// the CRC in completion record is not reliable when the job failed
+			d.encodedSize = storedSize(len(block))
+			d.crc = crc32.Update(prev, crc32.IEEETable, block)
 			return d.writeStoredBlock(block, last)
 		}
 		return d.completionRecord.CheckError()
 	}
 	d.crc = d.completionRecord.CRC
+	d.encodedSize = int(d.completionRecord.OutputSize)
 	if d.completionRecord.OutputSize == 0 {
 		return
 	}
+	if d.verifier != nil && !d.verifyBlock(block, prev) {
+		d.verifyFailures++
+		d.crc = crc32.Update(prev, crc32.IEEETable, block)
+		d.encodedSize = storedSize(len(block))
+		return d.writeStoredBlock(block, last)
+	}
 	// check for best compression
-	if d.completionRecord.OutputSize > uint32(len(block)+5) {
+	if d.completionRecord.OutputSize > uint32(storedSize(len(block))) {
 		return d.writeStoredBlock(block, last)
 	}
 	// copy the final bits to next output
@@ -427,16 +758,114 @@ func (d *Deflate) encodeBlock(aecs *iaa.CompressAECS, block []byte, last bool, h
 	return
 }
 
-// Close the underlying writer.
-func (d *Deflate) Close() error {
-	closer, ok := d.w.(io.Closer)
-	if ok {
-		return closer.Close()
+// writePipelinedBlock statistics the block while the previous block is being encoded,
+// and then submits the encode job of the block without waiting for it, see Pipeline.
+func (d *Deflate) writePipelinedBlock(block []byte, last bool) (n int, err error) {
+	d.stats.histogram = iaa.Histogram{}
+	err = d.statisticPipelined(block, &d.stats.histogram)
+	if err != nil {
+		d.discardPending()
+		return 0, err
+	}
+	// generate the huffman codes before waiting for the previous block
+	d.generateCodes(&d.stats.histogram)
+
+	// the header needs the bits left by the previous block
+	err = d.finishPending()
+	if err != nil {
+		return 0, err
+	}
+	aecs := &d.aecs[d.toggle]
+	aecs.Reset()
+	aecs.Histogram = d.stats.histogram
+	headerBits := d.writeHeader(&aecs.Histogram, &aecs.OutputAccumulatorData, last)
+
+	d.pendingCRC = d.crc
+	d.prepareEncode(aecs, block, headerBits)
+	d.pending = d.ctx.SubmitAsync(uintptr(unsafe.Pointer(&d.descriptor)), &d.completionRecord.Header)
+	d.pendingBlock = block
+	d.pendingLast = last
+	if last {
+		return len(block), d.finishPending()
+	}
+	return len(block), nil
+}
+
+// statisticPipelined statistics the block with the completion record of the pipelined mode,
+// the encode job in flight is not affected.
+func (d *Deflate) statisticPipelined(block []byte, histogram *iaa.Histogram) error {
+	d.descriptor.Reset()
+	d.stats.record.Reset()
+	d.statsJob(block, histogram, &d.stats.record)
+	job := d.ctx.SubmitAsync(uintptr(unsafe.Pointer(&d.descriptor)), &d.stats.record.Header)
+	status := iaa.StatusCode(job.Wait(d.busyPoll))
+	runtime.KeepAlive(histogram)
+	if status != iaa.Success {
+		return d.stats.record.CheckError()
 	}
 	return nil
 }
 
+// finishPending waits for the encode job in flight and writes the encoded block to the underlying writer.
+func (d *Deflate) finishPending() error {
+	if d.pendingBlock == nil {
+		return nil
+	}
+	status := iaa.StatusCode(d.pending.Wait(d.busyPoll))
+	block := d.pendingBlock
+	d.pendingBlock = nil
+	err := d.encodeResult(status, block, d.pendingLast, d.pendingCRC)
+	runtime.KeepAlive(block)
+	if err != nil {
+		return err
+	}
+	d.toggle ^= 1
+	return nil
+}
+
+// discardPending waits for the encode job in flight and drops its result.
+func (d *Deflate) discardPending() {
+	if d.pendingBlock == nil {
+		return
+	}
+	d.pending.Wait(d.busyPoll)
+	d.pendingBlock = nil
+}
+
+// Write writes data to the compression stream, the data is buffered and compressed block by block.
+func (d *Deflate) Write(data []byte) (n int, err error) {
+	return d.stream.write(data, d.writeBlock)
+}
+
+// Flush compresses all pending data and aligns the stream to a byte boundary,
+// so all data written so far can be decompressed by the reader. It's like flate.Writer.Flush.
+func (d *Deflate) Flush() error {
+	return d.stream.flush(d.writeBlock)
+}
+
+// Close compresses the pending data as the final block.
+// It does nothing if the stream has been finished (e.g. by ReadFrom).
+//
+// Close does not close the underlying writer like flate.Writer,
+// but the BufWriter (e.g. NewDeflateWriter) closes it.
+func (d *Deflate) Close() error {
+	return d.stream.close(d.writeBlock)
+}
+
+// writeStoredBlock writes the block as stored blocks, split by maxStoredBlockSize.
 func (d *Deflate) writeStoredBlock(block []byte, last bool) error {
+	for len(block) > maxStoredBlockSize {
+		err := d.writeOneStoredBlock(block[:maxStoredBlockSize], false)
+		if err != nil {
+			return err
+		}
+		block = block[maxStoredBlockSize:]
+	}
+	return d.writeOneStoredBlock(block, last)
+}
+
+// writeOneStoredBlock writes the block, not larger than maxStoredBlockSize, as a stored block.
+func (d *Deflate) writeOneStoredBlock(block []byte, last bool) error {
 	blockHdr := 0b000
 	if last {
 		blockHdr = 0b001
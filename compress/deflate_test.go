@@ -11,6 +11,9 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/intel/ixl-go/compress/internal/huffman"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
 	"github.com/intel/ixl-go/internal/testutil"
 )
 
@@ -49,6 +52,167 @@ func TestDeflate(t *testing.T) {
 	testDeflate(t, w)
 	w, _ = NewDeflate(io.Discard, BusyPoll())
 	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, PackageMerge())
+	testDeflate(t, w)
+	w, err := NewDeflate(io.Discard, BlockSize(1024*1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, BlockSize(1024*1024), PackageMerge())
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, BlockSize(1024*1024), AdaptiveMode())
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, BlockSize(1024*1024), FixedMode())
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, Pipeline())
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, Pipeline(), HuffmanOnly(), BusyPoll())
+	testDeflate(t, w)
+	w, _ = NewDeflate(io.Discard, Pipeline(), BlockSize(1024*1024), Verify())
+	testDeflate(t, w)
+}
+
+func TestPipeline(t *testing.T) {
+	for _, opts := range [][]Option{{}, {HuffmanOnly()}, {BlockSize(64 * 1024)}} {
+		if opt := newOption(append(opts, Pipeline())); !opt.pipeline || !opt.pipelineSupported() {
+			t.Fatal("expected pipeline to be supported", opts)
+		}
+	}
+	for _, opts := range [][]Option{{FixedMode()}, {AdaptiveMode()}, {ReuseTable(0.9)}} {
+		if newOption(opts).pipelineSupported() {
+			t.Fatal("expected pipeline not to be supported", opts)
+		}
+	}
+	if !Ready() {
+		return
+	}
+	_, err := NewDeflate(nil, Pipeline(), FixedMode())
+	if err != errors.InvalidArgument {
+		t.Fatal("expected invalid argument, got", err)
+	}
+	input := []byte(testutil.RandomText(300*1024 + 17))
+	buf := bytes.NewBuffer(nil)
+	w, err := NewDeflateWriter(buf, Pipeline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range [][]byte{input[:50000], input[50000:120000], input[120000:]} {
+		if _, err = w.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(flate.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	// all data written before Flush can be decompressed when Flush returns
+	buf.Reset()
+	w.Reset(buf)
+	if _, err = w.Write(input); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data = make([]byte, len(input))
+	if _, err = io.ReadFull(flate.NewReader(bytes.NewReader(buf.Bytes())), data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, input) {
+		t.Fatal("flushed data is not consistent with input")
+	}
+}
+
+func TestBlockSize(t *testing.T) {
+	if err := newOption([]Option{BlockSize(1024)}).err; err != errors.InvalidArgument {
+		t.Fatal("expected invalid argument, got", err)
+	}
+	opt := newOption([]Option{BlockSize(1024 * 1024)})
+	if opt.err != nil || opt.blockSize != 1024*1024 {
+		t.Fatal("unexpected block size", opt.blockSize, opt.err)
+	}
+	if newOption(nil).blockSize != defaultBlockSize {
+		t.Fatal("unexpected default block size")
+	}
+	if size := len(NewGzipWriter(nil, BlockSize(1024*1024)).buffer); size != 1024*1024 {
+		t.Fatal("unexpected buffer size", size)
+	}
+	if size := len(NewZlibWriter(nil).buffer); size != defaultBlockSize {
+		t.Fatal("unexpected buffer size", size)
+	}
+	if !Ready() {
+		return
+	}
+	_, err := NewDeflate(nil, BlockSize(int(iaa.LoadContext().MaxTransferSize())+1))
+	if err != errors.DataSizeTooLarge {
+		t.Fatal("expected data size too large, got", err)
+	}
+}
+
+func TestDeflate_writeStoredBlock(t *testing.T) {
+	for _, size := range []int{0, 100, maxStoredBlockSize, maxStoredBlockSize + 1, 200000} {
+		data := []byte(testutil.RandomText(size))
+		buf := bytes.NewBuffer(nil)
+		d := &Deflate{w: buf, output: make([]byte, storedSize(size)+1)}
+		if err := d.writeStoredBlock(data, true); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != storedSize(size) {
+			t.Fatalf("expected %d bytes, got %d", storedSize(size), buf.Len())
+		}
+		decompressed, err := io.ReadAll(flate.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Fatal("decompressed contents should be the same")
+		}
+	}
+}
+
+func TestPackageMerge(t *testing.T) {
+	if _, ok := newTreeGenerator(newOption(nil)).(*huffman.LenLimitedCode); !ok {
+		t.Fatal("expected LenLimitedCode by default")
+	}
+	if _, ok := newTreeGenerator(newOption([]Option{PackageMerge()})).(*huffman.PackageMergeCode); !ok {
+		t.Fatal("expected PackageMergeCode")
+	}
+}
+
+func TestDeflate_CompressAll(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	w, _ := NewDeflate(nil)
+	dst := make([]byte, 0, CompressBound(4096*1024))
+	for i := 2; i <= 4096*1024; i = i * 2 {
+		text := []byte(testutil.RandomText(i))
+		var err error
+		allocs := testing.AllocsPerRun(1, func() {
+			dst, err = w.CompressAll(dst[:0], text)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allocs != 0 {
+			t.Fatalf("expected no allocation, got %v", allocs)
+		}
+		data, err := io.ReadAll(flate.NewReader(bytes.NewReader(dst)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, text) {
+			t.Fatal("decompressed contents should be the same")
+		}
+	}
 }
 
 func FuzzDeflate(f *testing.F) {
@@ -118,6 +282,15 @@ func BenchmarkReusedRandomTextCompress(b *testing.B) {
 			}
 		})
 
+		w, _ = NewDeflateWriter(io.Discard, Pipeline())
+		b.Run(fmt.Sprintf("IAA pipelined deflate[%dk]", i), func(b *testing.B) {
+			for j := 0; j < b.N; j++ {
+				w.Reset(io.Discard)
+				_, _ = w.Write(text)
+				w.Close()
+			}
+		})
+
 		w, _ = NewDeflateWriter(io.Discard, FixedMode())
 		b.Run(fmt.Sprintf("IAA fixed deflate[%dk]", i), func(b *testing.B) {
 			for j := 0; j < b.N; j++ {
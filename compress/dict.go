@@ -0,0 +1,139 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// maxHistorySize is the history buffer size used by hardware.
+const maxHistorySize = 4096
+
+// history returns the part of dictionary that can be kept in the hardware history buffer.
+func history(dict []byte) []byte {
+	if len(dict) > maxHistorySize {
+		return dict[len(dict)-maxHistorySize:]
+	}
+	return dict
+}
+
+// NewDeflateDict is like NewDeflate but initializes the new Deflate with a preset dictionary.
+// The compressed data can only be decompressed by a decompressor initialized with the same dictionary,
+// see NewInflateDict.
+//
+// Notice:
+//
+//  1. The data is compressed by CPU (compress/flate), not by IAA, when the dictionary is not empty.
+//     The compression AECS only carries the CRC and the pending output bits between jobs,
+//     there is no history buffer which can be primed like the decompression (see NewInflateDict),
+//     so the matches against the dictionary can't be found by hardware.
+//     The mode and level options are mapped onto the nearest compress/flate level.
+//  2. Only the last 4KB of the dictionary is used, which is the size of the history buffer of hardware,
+//     so the data can be decompressed by NewInflateDict. The bytes before the last 4KB are ignored.
+//  3. The Verify option is rejected with errors.InvalidArgument when the dictionary is not empty,
+//     the blocks compressed by CPU are not verified.
+func NewDeflateDict(w io.Writer, dict []byte, opts ...Option) (*Deflate, error) {
+	if len(dict) != 0 && newOption(opts).verify {
+		return nil, errors.InvalidArgument
+	}
+	d, err := NewDeflate(w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(dict) == 0 {
+		return d, nil
+	}
+	d.soft, err = flate.NewWriterDict(w, softLevel(newOption(opts)), history(dict))
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// softLevel returns the compress/flate level which is the nearest to the option.
+func softLevel(opt *option) int {
+	switch opt.mode {
+	case modeStored:
+		return flate.NoCompression
+	case modeFixed:
+		return flate.BestSpeed
+	case modeHuffmanOnly:
+		return flate.HuffmanOnly
+	case modeDynamic, modeAdaptive:
+	}
+	return opt.level
+}
+
+func (d *Deflate) writeSoftBlock(block []byte, last bool) (n int, err error) {
+	if len(block) == 0 && !last {
+		// an empty non-final block aligns the stream, see blockBuffer.flush
+		return 0, d.soft.Flush()
+	}
+	d.crc = crc32.Update(d.crc, crc32.IEEETable, block)
+	n, err = d.soft.Write(block)
+	if err != nil {
+		return n, err
+	}
+	if last {
+		err = d.soft.Close()
+	}
+	return n, err
+}
+
+// NewInflateDict is like NewInflate but initializes the new Inflate with a preset dictionary.
+// The history buffer of hardware is primed by decompressing the dictionary as a stored block.
+// Only the last 4KB of the dictionary is used, which is the size of the history buffer of hardware,
+// the streams referring to the bytes before the last 4KB (e.g. compressed by compress/flate
+// with a larger dictionary) can't be decompressed.
+func NewInflateDict(r io.Reader, dict []byte, opts ...Option) (*Inflate, error) {
+	i, err := NewInflate(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	i.ResetDict(r, dict)
+	return i, nil
+}
+
+// ResetDict is like Reset but sets a new preset dictionary.
+func (i *Inflate) ResetDict(r io.Reader, dict []byte) {
+	i.Reset(r)
+	if len(dict) != 0 {
+		i.dict = history(dict)
+	}
+}
+
+// primeDictionary fills the history buffer of hardware by decompressing a stored block holding the dictionary,
+// then the real stream can be decompressed from the saved state.
+func (i *Inflate) primeDictionary() error {
+	if i.dictBuf == nil {
+		i.dictBuf = mem.Alloc64ByteAligned(2 * (maxHistorySize + 64))
+	}
+	size := len(i.dict)
+	input := i.dictBuf[:storedBlockHeaderSize+size]
+	output := i.dictBuf[maxHistorySize+64:][:size]
+
+	// BFINAL = 0, BTYPE = 00
+	input[0] = 0
+	binary.LittleEndian.PutUint16(input[1:3], uint16(size))
+	binary.LittleEndian.PutUint16(input[3:5], ^uint16(size))
+	copy(input[storedBlockHeaderSize:], i.dict)
+
+	i.cr.Reset()
+	i.desc.Reset()
+	i.decompressJob(input, output, &i.aecsPair[0])
+	status := i.submit()
+	if status != iaa.Success {
+		return i.cr.CheckError()
+	}
+	i.toggle ^= 1
+	i.state = middle
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestZlibDictHeader(t *testing.T) {
+	dict := []byte(testutil.RandomText(100))
+	buf := bytes.NewBuffer(nil)
+	w, err := zlib.NewWriterLevelDict(buf, DefaultCompression, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	hdr := appendZlibHeader(nil, DefaultCompression, dict)
+	if !bytes.Equal(hdr, buf.Bytes()[:6]) {
+		t.Fatalf("expected header %x, got %x", buf.Bytes()[:6], hdr)
+	}
+}
+
+func TestDeflateDict(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	dict := []byte(testutil.RandomText(8192))
+	message := append(append([]byte{}, dict[5000:6000]...), dict[7000:7500]...)
+
+	buf := bytes.NewBuffer(nil)
+	d, err := NewDeflateDict(buf, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.ReadFrom(bytes.NewReader(message))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if buf.Len() >= len(message)/2 {
+		t.Fatalf("the dictionary should be used, compressed size: %d", buf.Len())
+	}
+	compressed := append([]byte{}, buf.Bytes()...)
+
+	data, err := io.ReadAll(flate.NewReaderDict(bytes.NewReader(compressed), dict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, message) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	i, err := NewInflateDict(bytes.NewReader(compressed), dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, message) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
+
+func TestZlibDict(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	dict := []byte(testutil.RandomText(1024))
+	message := append(append([]byte{}, dict...), dict[:500]...)
+	z := NewZlibDict(nil, dict)
+	compressed, err := z.CompressAll(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := zlib.NewReaderDict(bytes.NewReader(compressed), dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, message) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"math"
+
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// Estimation is the result of Deflate.Estimate.
+type Estimation struct {
+	// Literals is the histogram of literal/length symbols (0-285) of the data.
+	Literals [286]uint32
+	// Distances is the histogram of distance symbols (0-29) of the data.
+	Distances [30]uint32
+	// InputSize is the size of the estimated data.
+	InputSize int
+	// Size is the predicted size of the deflate compressed data in bytes.
+	Size int
+}
+
+// Ratio returns the predicted compression ratio (InputSize/Size).
+func (e *Estimation) Ratio() float64 {
+	if e.Size == 0 {
+		return 0
+	}
+	return float64(e.InputSize) / float64(e.Size)
+}
+
+// Compressible reports whether the data is predicted to be smaller after compression.
+func (e *Estimation) Compressible() bool {
+	return e.Size < e.InputSize
+}
+
+// Estimate predicts the compressed size of the data without compressing it.
+//
+// The data is analysed by IAA in statistics mode block by block,
+// only the literal/length and distance histograms are produced,
+// then the compressed size of every block is predicted by the entropy of the histograms.
+// It's cheaper than compression, and can be used to skip compressing
+// incompressible data (e.g. already compressed media or encrypted data).
+//
+// Notice: the state of the stream is not changed, Estimate can be called between writes.
+func (d *Deflate) Estimate(data []byte) (e Estimation, err error) {
+	if d.estimateHist == nil {
+		d.estimateHist = mem.Alloc64Align[iaa.Histogram]()
+	}
+	e.InputSize = len(data)
+	for len(data) > 0 {
+		size := len(data)
+		if size > d.blockSize {
+			size = d.blockSize
+		}
+		block := data[:size]
+		data = data[size:]
+
+		histogram := d.estimateHist
+		*histogram = iaa.Histogram{}
+		err = d.statisticBlock(block, histogram)
+		if err != nil {
+			return e, err
+		}
+		for i, c := range histogram.LiteralCodes {
+			e.Literals[i] += uint32(c)
+		}
+		for i, c := range histogram.DistanceCodes {
+			e.Distances[i] += uint32(c)
+		}
+		bits := estimateBlockBits(histogram)
+		// the stored block is used if the compressed block is larger
+		stored := storedSize(size) * 8
+		if bits > stored {
+			bits = stored
+		}
+		e.Size += bits
+	}
+	if e.InputSize == 0 {
+		// an empty final block
+		e.Size = 8
+	}
+	e.Size = (e.Size + 7) / 8
+	return e, nil
+}
+
+// extra bits of the length symbols 257-285, see https://www.rfc-editor.org/rfc/rfc1951#section-3.2.5
+var lengthExtraBits = [29]int{
+	0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2,
+	3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0,
+}
+
+// extra bits of the distance symbols 0-29.
+var distanceExtraBits = [30]int{
+	0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6,
+	6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13,
+}
+
+// estimateBlockBits predicts the size in bits of a dynamic block by the entropy of the histogram,
+// the extra bits of lengths and distances and the approximate size of the block header are included.
+func estimateBlockBits(histogram *iaa.Histogram) int {
+	literals := histogram.LiteralCodes[:]
+	distances := histogram.DistanceCodes[:]
+	bits := entropyBits(literals) + entropyBits(distances)
+	for i, n := range lengthExtraBits {
+		bits += float64(int(literals[257+i]) * n)
+	}
+	for i, n := range distanceExtraBits {
+		bits += float64(int(distances[i]) * n)
+	}
+	// block header: BFINAL, BTYPE, HLIT, HDIST, HCLEN, code length codes,
+	// and about 4 bits per used symbol for the code lengths.
+	header := 3 + 5 + 5 + 4 + 19*3
+	for _, c := range literals {
+		if c != 0 {
+			header += 4
+		}
+	}
+	for _, c := range distances {
+		if c != 0 {
+			header += 4
+		}
+	}
+	return int(math.Ceil(bits)) + header
+}
+
+// entropyBits returns the size in bits of the symbols encoded by an ideal entropy coder.
+func entropyBits(counts []int32) float64 {
+	total := 0
+	for _, c := range counts {
+		total += int(c)
+	}
+	if total == 0 {
+		return 0
+	}
+	bits := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		// the huffman code length is at least 1 bit
+		bits += float64(c) * math.Max(1, -math.Log2(float64(c)/float64(total)))
+	}
+	return bits
+}
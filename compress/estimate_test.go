@@ -0,0 +1,81 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestEntropyBits(t *testing.T) {
+	if bits := entropyBits([]int32{0, 0, 0}); bits != 0 {
+		t.Fatalf("expected 0 bits, got %f", bits)
+	}
+	// a single symbol needs 1 bit at least
+	if bits := entropyBits([]int32{10, 0}); bits != 10 {
+		t.Fatalf("expected 10 bits, got %f", bits)
+	}
+	// uniform distribution of 4 symbols needs 2 bits per symbol
+	if bits := entropyBits([]int32{5, 5, 5, 5}); bits != 40 {
+		t.Fatalf("expected 40 bits, got %f", bits)
+	}
+}
+
+func TestEstimateBlockBits(t *testing.T) {
+	var his iaa.Histogram
+	// 1000 literals of a uniform distribution of 256 symbols
+	for i := 0; i < 256; i++ {
+		his.LiteralCodes[i] = 4
+	}
+	his.LiteralCodes[256] = 1
+	literalsOnly := estimateBlockBits(&his)
+	if literalsOnly < 1024*8 || literalsOnly > 1024*8+2000 {
+		t.Fatalf("unexpected bits: %d", literalsOnly)
+	}
+	// extra bits of lengths and distances are counted
+	his.LiteralCodes[284] = 1
+	his.DistanceCodes[29] = 1
+	if bits := estimateBlockBits(&his); bits < literalsOnly+5+13 {
+		t.Fatalf("extra bits are not counted: %d", bits)
+	}
+}
+
+func TestDeflate_Estimate(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	d, err := NewDeflate(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []byte(testutil.RandomText(100 * 1024))
+	e, err := d.Estimate(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Compressible() || e.Ratio() <= 1 {
+		t.Fatalf("text should be compressible, estimated size: %d", e.Size)
+	}
+	compressed, err := d.CompressAll(nil, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Size > len(compressed)*2 || e.Size < len(compressed)/2 {
+		t.Fatalf("estimated size %d is far from compressed size %d", e.Size, len(compressed))
+	}
+
+	random := make([]byte, 100*1024)
+	_, _ = rand.Read(random)
+	e, err = d.Estimate(random)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Compressible() {
+		t.Fatalf("random data should be incompressible, estimated size: %d", e.Size)
+	}
+}
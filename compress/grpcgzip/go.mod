@@ -0,0 +1,22 @@
+module github.com/intel/ixl-go/compress/grpcgzip
+
+go 1.20
+
+require (
+	github.com/intel/ixl-go v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.60.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.16.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231002182017-d307bd883b97 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
+
+// The replace directive is only for developing in this repository, it applies when this module is the main module.
+// grpcgzip requires the ixl-go release providing compress.Pool.GetGzipReader,
+// the requirement above must be updated to the tag of that release before grpcgzip is tagged.
+replace github.com/intel/ixl-go => ../..
@@ -0,0 +1,193 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package grpcgzip implements a gRPC compressor compressing the messages in gzip format using IAA.
+//
+// It's a separate module, so the ixl-go module doesn't depend on gRPC.
+// The compressor is registered by Register (overriding the built-in "gzip" compressor) or RegisterName:
+//
+//	if err := grpcgzip.Register(); err != nil {
+//		log.Println("the built-in gzip compressor is used:", err)
+//	}
+//
+// Notice: the history buffer used by IAA is 4KB, the same as compress.Inflate,
+// the messages compressed by the peers not using IAA (e.g. the built-in gzip compressor) may use the 32KB window,
+// they are decompressed by compress/gzip again if IAA fails to decompress them.
+// The first 1MB of the compressed message is kept in memory for it, if IAA fails after that,
+// or any decompression limit is exceeded, the error is returned without the fallback.
+package grpcgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the name registered by Register.
+const Name = "gzip"
+
+type compressor struct {
+	name string
+	pool *compress.Pool
+}
+
+// Register registers the compressor under the name "gzip", the built-in gzip compressor of gRPC is overridden.
+// The compressors and decompressors are created with the options and pooled.
+//
+// Register must be called at initialization time (not concurrently with the RPCs),
+// it returns an error and registers nothing if no IAA device is found.
+func Register(opts ...compress.Option) error {
+	return RegisterName(Name, opts...)
+}
+
+// RegisterName is like Register but registers the compressor under the given name.
+func RegisterName(name string, opts ...compress.Option) error {
+	if name == "" {
+		return errors.InvalidArgument
+	}
+	if !compress.Ready() {
+		return errors.NoHardwareDeviceDetected
+	}
+	encoding.RegisterCompressor(newCompressor(name, opts...))
+	return nil
+}
+
+func newCompressor(name string, opts ...compress.Option) *compressor {
+	return &compressor{name: name, pool: compress.NewPool(opts...)}
+}
+
+// Name returns the name of the compressor.
+func (c *compressor) Name() string {
+	return c.name
+}
+
+// Compress returns a writer compressing the message into `w`, the message is finished by Close.
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &writer{Gzip: c.pool.GetGzipWriter(w), pool: c.pool}, nil
+}
+
+// Decompress returns a reader decompressing the message from `r`,
+// the message is decompressed by compress/gzip if IAA fails to decompress it.
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	z := &reader{r: r, pool: c.pool}
+	var err error
+	z.z, err = c.pool.GetGzipReader(io.TeeReader(r, &z.recorded))
+	if canFallBack(err) {
+		err = z.fallBack(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// writer puts the Gzip back into the pool on close.
+type writer struct {
+	*compress.Gzip
+	pool *compress.Pool
+}
+
+func (w *writer) Close() error {
+	if w.Gzip == nil {
+		return errors.ErrWriterClosed
+	}
+	err := w.Gzip.Close()
+	w.pool.PutGzipWriter(w.Gzip)
+	w.Gzip = nil
+	return err
+}
+
+// reader puts the GzipReader back into the pool at the end of the message or on error,
+// and falls back to compress/gzip if IAA fails to decompress the message.
+type reader struct {
+	z        *compress.GzipReader
+	pool     *compress.Pool
+	r        io.Reader
+	recorded recorder // the compressed data read by IAA, replayed by the fallback
+	read     int64    // bytes returned
+	fallback *gzip.Reader
+	err      error
+}
+
+func (r *reader) Read(p []byte) (n int, err error) {
+	if r.fallback != nil {
+		return r.fallback.Read(p)
+	}
+	if r.z == nil {
+		return 0, r.err
+	}
+	n, err = r.z.Read(p)
+	r.read += int64(n)
+	if canFallBack(err) {
+		err = r.fallBack(err)
+	}
+	if err != nil {
+		r.release()
+		r.err = err
+	}
+	return n, err
+}
+
+// canFallBack reports whether compress/gzip may decompress the data IAA fails to decompress,
+// the limit errors are reported as they are.
+func canFallBack(err error) bool {
+	if _, ok := err.(*errors.LimitError); ok {
+		return false
+	}
+	_, ok := err.(errors.Error)
+	return ok
+}
+
+// fallBack decompresses the message from the beginning by compress/gzip, the bytes returned are skipped.
+// The error of IAA is returned if the compressed data read by IAA is not fully recorded.
+func (r *reader) fallBack(cause error) error {
+	r.release()
+	if r.recorded.overflow {
+		return cause
+	}
+	gr, err := gzip.NewReader(io.MultiReader(&r.recorded.buf, r.r))
+	if err != nil {
+		return err
+	}
+	if _, err = io.CopyN(io.Discard, gr, r.read); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	r.fallback = gr
+	return nil
+}
+
+// release puts the GzipReader back into the pool.
+func (r *reader) release() {
+	if r.z != nil {
+		r.pool.PutGzipReader(r.z)
+		r.z = nil
+	}
+}
+
+// maxRecorded is the max size of the compressed data kept for the fallback.
+const maxRecorded = 1 << 20
+
+// recorder keeps the data written to it until the size exceeds maxRecorded.
+type recorder struct {
+	buf      bytes.Buffer
+	overflow bool // the data is dropped as it's too large
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.overflow {
+		return len(p), nil
+	}
+	if r.buf.Len()+len(p) > maxRecorded {
+		r.overflow = true
+		r.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return r.buf.Write(p)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpcgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestRegisterName(t *testing.T) {
+	if err := RegisterName(""); err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+	if compress.Ready() {
+		t.Skip("IAA devices found")
+	}
+	if err := RegisterName("ixl-gzip"); err != errors.NoHardwareDeviceDetected {
+		t.Fatalf("expected no device error, got %v", err)
+	}
+	if encoding.GetCompressor("ixl-gzip") != nil {
+		t.Fatal("the compressor should not be registered without devices")
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	c := newCompressor(Name)
+	message := []byte(strings.Repeat("hello gRPC, ", 10000))
+	for i := 0; i < 3; i++ {
+		buf := bytes.NewBuffer(nil)
+		w, err := c.Compress(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Write(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// compatible with the standard gzip
+		sr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := io.ReadAll(sr)
+		if err != nil || !bytes.Equal(output, message) {
+			t.Fatal("the message is not decompressed by compress/gzip", err)
+		}
+
+		r, err := c.Decompress(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err = io.ReadAll(r)
+		if err != nil || !bytes.Equal(output, message) {
+			t.Fatal("the message is not decompressed", err)
+		}
+	}
+}
+
+func TestDecompress_Fallback(t *testing.T) {
+	// the repeated text is far away than 4KB, compress/gzip uses the 32KB window for it
+	message := bytes.Repeat([]byte(testutil.RandomText(10*1024)), 20)
+	buf := bytes.NewBuffer(nil)
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write(message)
+	_ = w.Close()
+	compressed := buf.Bytes()
+
+	c := newCompressor(Name)
+	r, err := c.Decompress(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(output, message) {
+		t.Fatal("the message is not decompressed", err)
+	}
+
+	r, err = c.Decompress(bytes.NewReader(compressed[:len(compressed)/2]))
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+}
+
+func TestDecompress_NoFallback(t *testing.T) {
+	r := &reader{r: strings.NewReader("")}
+	_, _ = r.recorded.Write(make([]byte, maxRecorded+1))
+	if !r.recorded.overflow || r.recorded.buf.Len() != 0 {
+		t.Fatal("expected the recorded data to be dropped")
+	}
+	if err := r.fallBack(errors.ErrCorruptedData); err != errors.ErrCorruptedData {
+		t.Fatalf("expected the error of IAA, got %v", err)
+	}
+	if canFallBack(&errors.LimitError{}) || !canFallBack(errors.ErrCorruptedData) || canFallBack(io.EOF) {
+		t.Fatal("unexpected fallback condition")
+	}
+	if !compress.Ready() {
+		return
+	}
+	buf := bytes.NewBuffer(nil)
+	w, _ := newCompressor(Name).Compress(buf)
+	_, _ = w.Write([]byte(testutil.RandomText(100 * 1024)))
+	_ = w.Close()
+	// the limit error is not retried by compress/gzip
+	z, err := newCompressor(Name, compress.MaxOutputSize(1024)).Decompress(buf)
+	if err == nil {
+		_, err = io.ReadAll(z)
+	}
+	if _, ok := err.(*errors.LimitError); !ok {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+}
+
+func TestBufconn(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	if err := RegisterName("ixl-gzip"); err != nil {
+		t.Fatal(err)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor("ixl-gzip")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	service := strings.Repeat("service", 1000)
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	// the unknown service is reported after the request is decompressed by the server
+	if err == nil || !strings.Contains(err.Error(), "unknown service") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+}
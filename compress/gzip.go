@@ -24,6 +24,7 @@ type Gzip struct {
 	w           io.Writer
 	buf         []byte
 	sum         int64
+	stream      blockBuffer
 	opts        []Option
 	compressor  *Deflate
 }
@@ -35,108 +36,112 @@ func NewGzip(w io.Writer, opts ...Option) *Gzip {
 	g := &Gzip{Header: Header{OS: 255}, w: w}
 	g.w = w
 	g.opts = opts
+	opt := newOption(opts)
+	g.level = opt.level
+	g.stream.blockSize = opt.blockSize
+	g.stream.pipeline = opt.pipeline
 	return g
 }
 
+func (g *Gzip) bufferSize() int {
+	return g.stream.bufferSize()
+}
+
+func (g *Gzip) pipelined() bool {
+	return g.stream.pipeline
+}
+
+func (g *Gzip) writer() io.Writer {
+	return g.w
+}
+
 // Gzip format: https://www.rfc-editor.org/rfc/rfc1952#page-4
 func (g *Gzip) writeHeader() (err error) {
+	g.buf, err = appendGzipHeader(g.buf[:0], &g.Header, g.UTF8, g.level)
+	if err != nil {
+		return err
+	}
+	_, err = g.w.Write(g.buf)
+	return err
+}
+
+// appendGzipHeader appends the gzip member header into buf.
+func appendGzipHeader(buf []byte, hdr *Header, utf8 bool, level int) (_ []byte, err error) {
 	flag := gzipFlag(0)
-	bCap := 10 // |ID1|ID2|CM|FLG|MTIME(4 byte)|XFL|OS|
-	if len(g.Extra) != 0 {
-		bCap += 2 + len(g.Extra) // xlen + bytes
+	if len(hdr.Extra) != 0 {
 		flag |= gzipFileExtra
 	}
-	if len(g.Name) != 0 {
-		bCap += len(g.Name) + 1 // zero-terminated
+	if len(hdr.Name) != 0 {
 		flag |= gzipFileName
 	}
-	if len(g.Comment) != 0 {
-		bCap += len(g.Comment) + 1 // zero-terminated
+	if len(hdr.Comment) != 0 {
 		flag |= gzipFileComment
 	}
-	if cap(g.buf) > bCap {
-		g.buf = g.buf[:bCap]
-	} else {
-		g.buf = make([]byte, bCap)
-	}
-	// ID1|ID2|CM
-	copy(g.buf[:3], fixedGzipHeader[:])
-	g.buf[3] = uint8(flag)
+	// ID1|ID2|CM|FLG
+	buf = append(buf, fixedGzipHeader[:]...)
+	buf = append(buf, uint8(flag))
 	// MTIME
-	sec := g.ModTime.Unix()
+	sec := hdr.ModTime.Unix()
 	if sec < 0 {
 		sec = 0
 	}
-	binary.LittleEndian.PutUint32(g.buf[4:8], uint32(sec))
-
-	level := 0
-	if g.level == flate.BestCompression {
-		level = 2
-	} else if g.level == flate.BestSpeed {
-		level = 4
-	}
-	g.buf[8] = byte(level)
-	g.buf[9] = g.OS
-	idx := 10
-	if len(g.Extra) != 0 {
-		binary.LittleEndian.PutUint16(g.buf[idx:idx+2], uint16(len(g.Extra)))
-		idx += 2
-		copy(g.buf[idx:], g.Extra)
-		idx += len(g.Extra)
-	}
-	if len(g.Name) != 0 {
-		idx, err = g.writeHeaderStr(idx, g.Name)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(sec))
+
+	xfl := byte(0)
+	if level == flate.BestCompression {
+		xfl = 2
+	} else if level == flate.BestSpeed {
+		xfl = 4
+	}
+	buf = append(buf, xfl, hdr.OS)
+	if len(hdr.Extra) != 0 {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(hdr.Extra)))
+		buf = append(buf, hdr.Extra...)
+	}
+	if len(hdr.Name) != 0 {
+		buf, err = appendHeaderStr(buf, hdr.Name, utf8)
 		if err != nil {
-			return err
+			return buf, err
 		}
 	}
-	if len(g.Comment) != 0 {
-		idx, err = g.writeHeaderStr(idx, g.Comment)
+	if len(hdr.Comment) != 0 {
+		buf, err = appendHeaderStr(buf, hdr.Comment, utf8)
 		if err != nil {
-			return err
+			return buf, err
 		}
 	}
-	_, err = g.w.Write(g.buf[:idx])
-	return err
+	return buf, nil
 }
 
-func (g *Gzip) writeHeaderStr(idx int, str string) (nidx int, err error) {
-	if g.UTF8 {
+// appendHeaderStr appends a zero-terminated string into buf,
+// the string is encoded as Latin-1 unless utf8 is true.
+func appendHeaderStr(buf []byte, str string, utf8 bool) ([]byte, error) {
+	if utf8 {
 		for _, char := range str {
 			if char == 0 {
-				return idx, errors.ErrZeroByte
+				return buf, errors.ErrZeroByte
 			}
 		}
-		copy(g.buf[idx:], []byte(g.Name))
-		idx += len(g.Name)
-		g.buf[idx] = 0
-		idx++
-		return idx, nil
+		buf = append(buf, str...)
+		return append(buf, 0), nil
 	}
 	safe := true
 	for _, char := range str {
 		if char == 0 || char > 0xff {
-			return idx, errors.ErrNonLatin1Header
+			return buf, errors.ErrNonLatin1Header
 		}
 		if char > 0x7f {
 			safe = false
-			break
 		}
 	}
 	if safe {
-		copy(g.buf[idx:], []byte(g.Name))
-		idx += len(g.Name)
-		g.buf[idx] = 0
-		idx++
-		return idx, nil
+		buf = append(buf, str...)
+		return append(buf, 0), nil
 	}
 	for _, char := range str {
-		g.buf[idx] = byte(char)
-		idx++
+		buf = append(buf, byte(char))
 	}
-	g.buf[idx] = 0
-	idx++
-	return idx, nil
+	return append(buf, 0), nil
 }
 
 // writeBlock compresses the block and writes it to underlying writer.
@@ -147,6 +152,9 @@ func (g *Gzip) writeHeaderStr(idx int, str string) (nidx int, err error) {
 //  2. The `last` argument must be true if the block is the last block in the stream.
 //  3. For most scenarios, you should use the `ReadFrom` method.
 func (g *Gzip) writeBlock(block []byte, last bool) (n int, err error) {
+	if last {
+		g.stream.closed = true
+	}
 	if !g.wroteHeader {
 		err = g.writeHeader()
 		if err != nil {
@@ -183,11 +191,12 @@ func (g *Gzip) ReadFrom(reader io.Reader) (n int64, err error) {
 		}
 	}
 	if g.compressor == nil {
-		g.compressor, err = NewDeflate(g.w)
+		g.compressor, err = NewDeflate(g.w, g.opts...)
 		if err != nil {
 			return 0, err
 		}
 	}
+	g.stream.closed = true
 	n, err = g.compressor.ReadFrom(reader)
 	if err != nil && err != io.EOF {
 		return n, err
@@ -196,6 +205,30 @@ func (g *Gzip) ReadFrom(reader io.Reader) (n int64, err error) {
 	return n, err
 }
 
+// CompressAll compresses all data in src as a gzip member and appends the result to dst.
+// The src may be larger than the device's max transfer size.
+//
+// No memory is allocated if dst has enough capacity, see CompressBound.
+func (g *Gzip) CompressAll(dst, src []byte) (_ []byte, err error) {
+	if g.compressor == nil {
+		g.compressor, err = NewDeflate(g.w, g.opts...)
+		if err != nil {
+			return dst, err
+		}
+	}
+	dst, err = appendGzipHeader(dst, &g.Header, g.UTF8, g.level)
+	if err != nil {
+		return dst, err
+	}
+	dst, crc, err := g.compressor.compressAll(dst, src)
+	if err != nil {
+		return dst, err
+	}
+	dst = binary.LittleEndian.AppendUint32(dst, crc)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(src)))
+	return dst, nil
+}
+
 func (g *Gzip) writeTailer(n int64) error {
 	crc := g.compressor.crc
 	buf := g.buf[:8]
@@ -210,19 +243,32 @@ func (g *Gzip) Reset(w io.Writer) {
 	g.w = w
 	g.sum = 0
 	g.wroteHeader = false
+	g.stream.reset()
 	g.Header = Header{OS: 255}
 	if g.compressor != nil {
 		g.compressor.Reset(g.w)
 	}
 }
 
-// Close the writer.
+// Write writes data to the gzip stream, the data is buffered and compressed block by block.
+// The header is written by the first Write.
+func (g *Gzip) Write(data []byte) (n int, err error) {
+	return g.stream.write(data, g.writeBlock)
+}
+
+// Flush compresses all pending data and aligns the stream to a byte boundary,
+// so all data written so far can be decompressed by the reader. It's like gzip.Writer.Flush.
+func (g *Gzip) Flush() error {
+	return g.stream.flush(g.writeBlock)
+}
+
+// Close compresses the pending data as the final block and writes the gzip trailer.
+// It does nothing if the stream has been finished (e.g. by ReadFrom).
+//
+// Close does not close the underlying writer like flate.Writer,
+// but the BufWriter (e.g. NewGzipWriter) closes it.
 func (g *Gzip) Close() error {
-	closer, ok := g.w.(io.Closer)
-	if ok {
-		return closer.Close()
-	}
-	return nil
+	return g.stream.close(g.writeBlock)
 }
 
 type gzipFlag uint8
@@ -0,0 +1,53 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package gzip provides the gzip writer of compress/gzip using IAA,
+// the programs can switch to IAA by changing the import path:
+//
+//	import gzip "github.com/intel/ixl-go/compress/gzip"
+//
+//	w, err := gzip.NewWriterLevel(file, gzip.BestSpeed)
+//
+// The levels are mapped onto the hardware modes, see compress.Level.
+// The output can be decompressed by any gzip reader, the streams compressed by IAA use a 4KB history window.
+//
+// Notice: the writer returns errors.NoHardwareDeviceDetected on the first write if no IAA device is found,
+// use compress.Ready to check the devices.
+package gzip
+
+import (
+	"io"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+// The compression levels, the same as compress/gzip.
+const (
+	NoCompression      = compress.NoCompression
+	BestSpeed          = compress.BestSpeed
+	BestCompression    = compress.BestCompression
+	DefaultCompression = compress.DefaultCompression
+	HuffmanOnly        = compress.HuffmanOnlyLevel
+)
+
+// Header is the gzip header, the same as gzip.Header.
+type Header = compress.Header
+
+// Writer is an io.WriteCloser with Flush and Reset like gzip.Writer,
+// the exported Header fields are written into the gzip header by the first Write, Flush or Close.
+type Writer = compress.Gzip
+
+// NewWriter returns a new Writer compressing the data written to it into w with DefaultCompression.
+func NewWriter(w io.Writer) *Writer {
+	return compress.NewGzip(w)
+}
+
+// NewWriterLevel is like NewWriter but specifies the compression level instead of assuming DefaultCompression.
+// The error is errors.InvalidCompressionLevel if the level is not valid.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	if level < HuffmanOnly || level > BestCompression {
+		return nil, errors.InvalidCompressionLevel
+	}
+	return compress.NewGzip(w, compress.Level(level)), nil
+}
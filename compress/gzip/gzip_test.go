@@ -0,0 +1,64 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package gzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestNewWriterLevel(t *testing.T) {
+	for _, level := range []int{-3, 10} {
+		if _, err := NewWriterLevel(io.Discard, level); err != errors.InvalidCompressionLevel {
+			t.Fatalf("level %d: expected invalid compression level error, got %v", level, err)
+		}
+	}
+	for level := HuffmanOnly; level <= BestCompression; level++ {
+		if _, err := NewWriterLevel(io.Discard, level); err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+	}
+	if HuffmanOnly != gzip.HuffmanOnly || DefaultCompression != gzip.DefaultCompression ||
+		NoCompression != gzip.NoCompression || BestSpeed != gzip.BestSpeed || BestCompression != gzip.BestCompression {
+		t.Fatal("the levels are not the same as compress/gzip")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	for _, level := range []int{DefaultCompression, BestSpeed, BestCompression} {
+		buf := bytes.NewBuffer(nil)
+		w, err := NewWriterLevel(buf, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Name = "input.txt"
+		if _, err = w.Write(input); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r, err := gzip.NewReader(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, input) || r.Name != "input.txt" {
+			t.Fatalf("level %d: decompressed data is not consistent with input", level)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+// headerReader reads bytes of the gzip header, the bytes read are counted and checksummed.
+type headerReader struct {
+	r   io.ByteReader
+	n   int
+	crc uint32
+	one [1]byte
+}
+
+func (h *headerReader) readByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err != nil {
+		if err == io.EOF && h.n != 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	h.n++
+	h.one[0] = b
+	h.crc = crc32.Update(h.crc, crc32.IEEETable, h.one[:])
+	return b, nil
+}
+
+func (h *headerReader) read(p []byte) error {
+	for i := range p {
+		b, err := h.readByte()
+		if err != nil {
+			return err
+		}
+		p[i] = b
+	}
+	return nil
+}
+
+// readString reads a zero-terminated Latin-1 string, and converts it to UTF-8.
+func (h *headerReader) readString() (string, error) {
+	var str []rune
+	for {
+		b, err := h.readByte()
+		if err != nil {
+			return "", err
+		}
+		if b == 0 {
+			return string(str), nil
+		}
+		str = append(str, rune(b))
+	}
+}
+
+// readGzipHeader reads the gzip member header from `r` into `hdr`, it returns the size of the header.
+//
+// Gzip format: https://www.rfc-editor.org/rfc/rfc1952#page-5
+func readGzipHeader(r io.ByteReader, hdr *Header) (n int, err error) {
+	h := &headerReader{r: r}
+	var fixed [10]byte
+	if err = h.read(fixed[:]); err != nil {
+		return h.n, err
+	}
+	if fixed[0] != fixedGzipHeader[0] || fixed[1] != fixedGzipHeader[1] || fixed[2] != fixedGzipHeader[2] {
+		return h.n, errors.ErrGzipHeader
+	}
+	flag := gzipFlag(fixed[3])
+	*hdr = Header{OS: fixed[9]}
+	if sec := binary.LittleEndian.Uint32(fixed[4:8]); sec > 0 {
+		hdr.ModTime = time.Unix(int64(sec), 0)
+	}
+	if flag&gzipFileExtra != 0 {
+		var size [2]byte
+		if err = h.read(size[:]); err != nil {
+			return h.n, err
+		}
+		hdr.Extra = make([]byte, binary.LittleEndian.Uint16(size[:]))
+		if err = h.read(hdr.Extra); err != nil {
+			return h.n, err
+		}
+	}
+	if flag&gzipFileName != 0 {
+		if hdr.Name, err = h.readString(); err != nil {
+			return h.n, err
+		}
+	}
+	if flag&gzipFileComment != 0 {
+		if hdr.Comment, err = h.readString(); err != nil {
+			return h.n, err
+		}
+	}
+	if flag&gzipFileHCRC != 0 {
+		crc := uint16(h.crc)
+		var sum [2]byte
+		if err = h.read(sum[:]); err != nil {
+			return h.n, err
+		}
+		if binary.LittleEndian.Uint16(sum[:]) != crc {
+			return h.n, errors.ErrGzipHeader
+		}
+	}
+	return h.n, nil
+}
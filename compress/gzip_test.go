@@ -6,6 +6,7 @@ package compress
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/rand"
 	"io"
 	"testing"
 
@@ -41,6 +42,44 @@ func TestGzip_ReadFrom(t *testing.T) {
 	}
 }
 
+func TestGzip_BlockSize(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	// the incompressible data is written as multiple stored blocks
+	random := make([]byte, 300*1024)
+	_, _ = rand.Read(random)
+	input := append([]byte(testutil.RandomText(2*1024*1024)), random...)
+	output := bytes.NewBuffer(nil)
+	w := NewGzipWriter(output, BlockSize(512*1024))
+	for data := input; len(data) > 0; {
+		size := 100000
+		if size > len(data) {
+			size = len(data)
+		}
+		_, err := w.Write(data[:size])
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = data[size:]
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
+
 // TestGzip_ReadFromWithName compatibility testing
 func TestGzip_ReadFromWithName(t *testing.T) {
 	if !Ready() {
@@ -101,3 +140,30 @@ func TestGzip_WriteBlock(t *testing.T) {
 		}
 	}
 }
+
+// TestGzip_CompressAll compatibility testing
+func TestGzip_CompressAll(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	g := NewGzip(nil)
+	g.Name = "hallo.txt"
+	for i := 2; i < 1024*1024; i = i * 2 {
+		input := testutil.RandomText(i)
+		output, err := g.CompressAll(nil, []byte(input))
+		if err != nil {
+			t.Fatal("error happened while gzip:", err)
+		}
+		sg, err := gzip.NewReader(bytes.NewReader(output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		soutput, err := io.ReadAll(sg)
+		if err != nil {
+			t.Fatal("error happened while gunzip", i, err)
+		}
+		if string(soutput) != input {
+			t.Fatal("decompressed data is not consistent with input")
+		}
+	}
+}
@@ -28,9 +28,9 @@ type dynamicHeader struct {
 	countCache  []int32
 }
 
-func newDynamicHeader() *dynamicHeader {
+func newDynamicHeader(generator huffman.TreeGenerator) *dynamicHeader {
 	return &dynamicHeader{
-		generator:  huffman.NewLenLimitedCode(),
+		generator:  generator,
 		histogram:  make([]int32, 19),
 		rcodes:     make([]uint16, 19),
 		source:     make([]uint8, 286+30+1),
@@ -0,0 +1,182 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package httpgzip
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/intel/ixl-go/compress"
+)
+
+type handler struct {
+	next    http.Handler
+	minSize int
+	pool    *compress.Pool
+}
+
+// NewHandler returns a middleware compressing the responses of `next`.
+//
+// The content coding is negotiated by the Accept-Encoding header of the request, gzip is preferred to deflate.
+// A response is sent uncompressed if it's smaller than the min size (see MinSize),
+// or it has a Content-Encoding header already, or it has no body.
+// The compressed response has the Content-Encoding header and no Content-Length header,
+// and all responses have "Accept-Encoding" in the Vary header.
+//
+// The compressors are pooled. If no IAA device is found, the responses are sent uncompressed.
+func NewHandler(next http.Handler, opts ...Option) (http.Handler, error) {
+	c := &config{minSize: DefaultMinSize}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &handler{next: next, minSize: c.minSize, pool: compress.NewPool(c.opts...)}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiate(r.Header.Get("Accept-Encoding"))
+	if encoding == "" || r.Method == http.MethodHead || !compress.Ready() {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	rw := &responseWriter{ResponseWriter: w, h: h, encoding: encoding, status: http.StatusOK}
+	defer rw.close()
+	h.next.ServeHTTP(rw, r)
+}
+
+// responseWriter buffers the response until it's large enough to be compressed.
+type responseWriter struct {
+	http.ResponseWriter
+	h        *handler
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool           // whether the response is compressed is decided
+	w        io.WriteCloser // the compressor, nil if the response is not compressed
+	gzip     *compress.Gzip
+	zlib     *compress.Zlib
+	err      error
+}
+
+// WriteHeader records the status code, the header is written when the response is decided to be compressed or not.
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.decided {
+		return
+	}
+	if status < http.StatusOK {
+		// informational responses
+		rw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	rw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		rw.decide(false)
+	}
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.err != nil {
+		return 0, rw.err
+	}
+	if !rw.decided {
+		rw.buf = append(rw.buf, p...)
+		if len(rw.buf) < rw.h.minSize {
+			return len(p), nil
+		}
+		rw.decide(true)
+		return len(p), rw.err
+	}
+	if rw.w == nil {
+		return rw.ResponseWriter.Write(p)
+	}
+	n, err := rw.w.Write(p)
+	if err != nil {
+		rw.err = err
+	}
+	return n, err
+}
+
+// decide writes the header and the buffered data, the response is compressed if `compressed` is true
+// and the handler doesn't set a Content-Encoding.
+func (rw *responseWriter) decide(compressed bool) {
+	rw.decided = true
+	header := rw.Header()
+	if header.Get("Content-Encoding") != "" {
+		compressed = false
+	}
+	if header.Get("Content-Type") == "" && len(rw.buf) != 0 {
+		// the net/http sniffs the content type from the compressed data otherwise
+		header.Set("Content-Type", http.DetectContentType(rw.buf))
+	}
+	if compressed {
+		header.Set("Content-Encoding", rw.encoding)
+		header.Del("Content-Length")
+		switch rw.encoding {
+		case encodingGzip:
+			rw.gzip = rw.h.pool.GetGzipWriter(rw.ResponseWriter)
+			rw.w = rw.gzip
+		case encodingDeflate:
+			rw.zlib = rw.h.pool.GetZlibWriter(rw.ResponseWriter)
+			rw.w = rw.zlib
+		}
+	}
+	rw.ResponseWriter.WriteHeader(rw.status)
+	if len(rw.buf) == 0 {
+		return
+	}
+	if rw.w == nil {
+		_, rw.err = rw.ResponseWriter.Write(rw.buf)
+	} else {
+		_, rw.err = rw.w.Write(rw.buf)
+	}
+	rw.buf = nil
+}
+
+// Flush compresses all buffered data and flushes it to the client,
+// the response is compressed if the buffered data is not empty.
+func (rw *responseWriter) Flush() {
+	if !rw.decided {
+		rw.decide(len(rw.buf) != 0)
+	}
+	if rw.err != nil {
+		return
+	}
+	switch {
+	case rw.gzip != nil:
+		rw.err = rw.gzip.Flush()
+	case rw.zlib != nil:
+		rw.err = rw.zlib.Flush()
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok && rw.err == nil {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, it's used by the http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// close finishes the response after the handler returns, and puts the compressor back into the pool.
+func (rw *responseWriter) close() {
+	if !rw.decided {
+		rw.decide(len(rw.buf) >= rw.h.minSize && len(rw.buf) != 0)
+	}
+	if rw.w == nil {
+		return
+	}
+	if rw.err == nil {
+		rw.err = rw.w.Close()
+	}
+	if rw.gzip != nil {
+		rw.h.pool.PutGzipWriter(rw.gzip)
+	}
+	if rw.zlib != nil {
+		rw.h.pool.PutZlibWriter(rw.zlib)
+	}
+	rw.w, rw.gzip, rw.zlib = nil, nil, nil
+}
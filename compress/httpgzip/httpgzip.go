@@ -0,0 +1,99 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package httpgzip compresses HTTP responses using IAA.
+//
+// The Handler is a server middleware compressing the responses in gzip or deflate (zlib) format,
+// the Transport is a client RoundTripper decompressing the gzip responses.
+//
+// Notice: the history buffer used by IAA is 4KB, the same as compress.Inflate,
+// the Transport decompresses the responses not compressed by IAA by compress/gzip if IAA fails to decompress them.
+package httpgzip
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+// Content codings supported by the Handler.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// DefaultMinSize is the default min size of the response to be compressed.
+const DefaultMinSize = 1024
+
+type config struct {
+	minSize int
+	opts    []compress.Option
+	err     error
+}
+
+// Option configures the Handler.
+type Option func(c *config)
+
+// MinSize sets the min size of the response to be compressed, the smaller responses are sent uncompressed.
+// The response is compressed anyway if the handler flushes it before the size is reached.
+func MinSize(size int) Option {
+	return func(c *config) {
+		if size < 0 {
+			c.err = errors.InvalidArgument
+			return
+		}
+		c.minSize = size
+	}
+}
+
+// CompressOptions sets the options used to create the compressors.
+func CompressOptions(opts ...compress.Option) Option {
+	return func(c *config) {
+		c.opts = opts
+	}
+}
+
+// negotiate chooses the content coding from the Accept-Encoding header,
+// gzip is preferred if gzip and deflate are accepted with the same quality.
+// It returns an empty string if neither gzip nor deflate is acceptable.
+func negotiate(acceptEncoding string) string {
+	gzipQ, deflateQ, anyQ := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				v = 0
+			}
+			q = v
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case encodingGzip, "x-gzip":
+			gzipQ = q
+		case encodingDeflate:
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return encodingGzip
+	case deflateQ > 0:
+		return encodingDeflate
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package httpgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestNegotiate(t *testing.T) {
+	for _, c := range []struct {
+		accept   string
+		encoding string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", encodingGzip},
+		{"deflate", encodingDeflate},
+		{"gzip, deflate, br", encodingGzip},
+		{"deflate, gzip", encodingGzip},
+		{"gzip;q=0.5, deflate", encodingDeflate},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"GZIP ; Q=0.8", encodingGzip},
+		{"*", encodingGzip},
+		{"*;q=0.1, gzip;q=0", encodingDeflate},
+		{"br, x-gzip", encodingGzip},
+	} {
+		if encoding := negotiate(c.accept); encoding != c.encoding {
+			t.Fatalf("expected %q for %q, got %q", c.encoding, c.accept, encoding)
+		}
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := NewHandler(http.NotFoundHandler(), MinSize(-1))
+	if err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
+
+func get(t *testing.T, url string, acceptEncoding string) (*http.Response, []byte) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var r io.Reader = resp.Body
+	switch resp.Header.Get("Content-Encoding") {
+	case encodingGzip:
+		r, err = gzip.NewReader(r)
+	case encodingDeflate:
+		r, err = zlib.NewReader(r)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestHandler(t *testing.T) {
+	text := testutil.RandomText(100 * 1024)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(text); i += 10000 {
+			end := i + 10000
+			if end > len(text) {
+				end = len(text)
+			}
+			_, _ = io.WriteString(w, text[i:end])
+		}
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	})
+	mux.HandleFunc("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		_, _ = io.WriteString(w, text)
+	})
+	h, err := NewHandler(mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	for _, accept := range []string{"gzip", "deflate", ""} {
+		resp, body := get(t, server.URL+"/large", accept)
+		if string(body) != text {
+			t.Fatal("unexpected response body")
+		}
+		expected := accept
+		if !compress.Ready() {
+			expected = ""
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != expected {
+			t.Fatalf("expected encoding %q, got %q", expected, encoding)
+		}
+		if resp.Header.Get("Vary") != "Accept-Encoding" {
+			t.Fatal("Vary header is not set")
+		}
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+			t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+		}
+	}
+
+	resp, body := get(t, server.URL+"/small", "gzip")
+	if string(body) != "hello" || resp.Header.Get("Content-Encoding") != "" {
+		t.Fatal("small response should not be compressed")
+	}
+	resp, _ = get(t, server.URL+"/encoded", "gzip")
+	if resp.Header.Get("Content-Encoding") != "br" {
+		t.Fatal("encoded response should not be compressed")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	text := testutil.RandomText(100 * 1024)
+	h, err := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, text)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Uncompressed || resp.Header.Get("Content-Encoding") != "" {
+			t.Fatal("response is not decompressed by the transport")
+		}
+		if string(body) != text {
+			t.Fatal("unexpected response body")
+		}
+	}
+}
+
+func TestTransport_Fallback(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	// the repeated text is far away than 4KB, compress/gzip uses the 32KB window for it
+	text := strings.Repeat(testutil.RandomText(10*1024), 20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", encodingGzip)
+		gw := gzip.NewWriter(w)
+		_, _ = io.WriteString(gw, text)
+		_ = gw.Close()
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != text {
+		t.Fatal("unexpected response body")
+	}
+}
+
+func TestTransport_Limit(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	h, err := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testutil.RandomText(100*1024))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	// the limit error is not retried by compress/gzip
+	client := &http.Client{Transport: NewTransport(nil, compress.MaxOutputSize(1024))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if _, ok := err.(*errors.LimitError); !ok {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+}
+
+func TestGzipBody_Fallback(t *testing.T) {
+	text := testutil.RandomText(10 * 1024)
+	buf := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(buf)
+	_, _ = io.WriteString(gw, text)
+	_ = gw.Close()
+
+	// the body is decompressed by compress/gzip if no IAA device is found
+	b := &gzipBody{body: io.NopCloser(bytes.NewReader(buf.Bytes())), pool: compress.NewPool()}
+	body, err := io.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != text {
+		t.Fatal("unexpected response body")
+	}
+
+	// the error is returned if the recorded data is dropped
+	b = &gzipBody{body: io.NopCloser(bytes.NewReader(buf.Bytes())), pool: compress.NewPool()}
+	_, _ = b.recorded.Write(make([]byte, maxRecorded+1))
+	if !b.recorded.overflow || b.recorded.buf.Len() != 0 {
+		t.Fatal("expected the recorded data to be dropped")
+	}
+	if err = b.fallBack(errors.ErrCorruptedData); err != errors.ErrCorruptedData {
+		t.Fatalf("expected the error of IAA, got %v", err)
+	}
+	if canFallBack(&errors.LimitError{}) || !canFallBack(errors.ErrCorruptedData) || canFallBack(io.EOF) {
+		t.Fatal("unexpected fallback condition")
+	}
+}
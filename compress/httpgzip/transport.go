@@ -0,0 +1,184 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package httpgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+// Transport is a http.RoundTripper requesting gzip compressed responses and decompressing them using IAA.
+//
+// The same as the http.Transport, the response is only decompressed if the Accept-Encoding header
+// is added by the Transport, and the decompressed response has no Content-Encoding and Content-Length headers.
+// If no IAA device is found, the request is sent by the base RoundTripper directly.
+//
+// The responses compressed by other compressors may use the 32KB history window, which is not supported by IAA,
+// the response body is decompressed by compress/gzip again from the beginning if IAA fails to decompress it,
+// so the first 1MB of the compressed data read by IAA is kept in memory until the body is closed.
+// If IAA fails after that, or any decompression limit is exceeded, the error is returned without the fallback.
+type Transport struct {
+	// Base is the RoundTripper sending the requests, the http.DefaultTransport is used if it's nil.
+	Base http.RoundTripper
+
+	pool *compress.Pool
+}
+
+// NewTransport creates a new Transport sending the requests by `base`,
+// the decompressors are created with the options.
+func NewTransport(base http.RoundTripper, opts ...compress.Option) *Transport {
+	return &Transport{Base: base, pool: compress.NewPool(opts...)}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !compress.Ready() || req.Header.Get("Accept-Encoding") != "" ||
+		req.Header.Get("Range") != "" || req.Method == http.MethodHead {
+		return t.base().RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", encodingGzip)
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), encodingGzip) || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, nil
+	}
+	pool := t.pool
+	if pool == nil {
+		pool = defaultPool
+	}
+	resp.Body = &gzipBody{body: resp.Body, pool: pool}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// defaultPool is used by the Transport not created by NewTransport.
+var defaultPool = compress.NewPool()
+
+// gzipBody decompresses the response body lazily,
+// and falls back to compress/gzip if IAA fails to decompress it.
+type gzipBody struct {
+	body     io.ReadCloser
+	pool     *compress.Pool
+	reader   *compress.GzipReader
+	recorded recorder // the compressed data read by IAA, replayed by the fallback
+	read     int64    // bytes returned
+	fallback *gzip.Reader
+	err      error
+}
+
+func (b *gzipBody) Read(p []byte) (n int, err error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.fallback != nil {
+		return b.fallback.Read(p)
+	}
+	if b.reader == nil {
+		b.reader, err = b.pool.GetGzipReader(io.TeeReader(b.body, &b.recorded))
+		if err != nil {
+			if canFallBack(err) {
+				err = b.fallBack(err)
+			}
+			b.err = err
+			return 0, err
+		}
+	}
+	n, err = b.reader.Read(p)
+	b.read += int64(n)
+	if canFallBack(err) {
+		if err = b.fallBack(err); err != nil {
+			b.err = err
+		}
+	}
+	return n, err
+}
+
+// canFallBack reports whether compress/gzip may decompress the data IAA fails to decompress,
+// the limit errors are reported as they are.
+func canFallBack(err error) bool {
+	if _, ok := err.(*errors.LimitError); ok {
+		return false
+	}
+	_, ok := err.(errors.Error)
+	return ok
+}
+
+// fallBack decompresses the body from the beginning by compress/gzip, the bytes returned are skipped.
+// The error of IAA is returned if the compressed data read by IAA is not fully recorded.
+func (b *gzipBody) fallBack(cause error) error {
+	b.release()
+	if b.recorded.overflow {
+		return cause
+	}
+	gr, err := gzip.NewReader(io.MultiReader(&b.recorded.buf, b.body))
+	if err != nil {
+		return err
+	}
+	if _, err = io.CopyN(io.Discard, gr, b.read); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	b.fallback = gr
+	return nil
+}
+
+// release puts the decompressor back into the pool.
+func (b *gzipBody) release() {
+	if b.reader != nil {
+		b.pool.PutGzipReader(b.reader)
+		b.reader = nil
+	}
+}
+
+// Close puts the decompressor back into the pool and closes the response body.
+func (b *gzipBody) Close() error {
+	b.release()
+	b.recorded = recorder{}
+	if b.err == nil {
+		b.err = http.ErrBodyReadAfterClose
+	}
+	return b.body.Close()
+}
+
+// maxRecorded is the max size of the compressed data kept for the fallback.
+const maxRecorded = 1 << 20
+
+// recorder keeps the data written to it until the size exceeds maxRecorded.
+type recorder struct {
+	buf      bytes.Buffer
+	overflow bool // the data is dropped as it's too large
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.overflow {
+		return len(p), nil
+	}
+	if r.buf.Len()+len(p) > maxRecorded {
+		r.overflow = true
+		r.buf = bytes.Buffer{}
+		return len(p), nil
+	}
+	return r.buf.Write(p)
+}
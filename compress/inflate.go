@@ -4,6 +4,7 @@
 package compress
 
 import (
+	"bufio"
 	"io"
 	"runtime"
 	"unsafe"
@@ -20,6 +21,14 @@ import (
 // or the whole stream must not larger than 4KB.
 // This because the standard deflate's history buffer size is 32KB,
 // but the IAA deflate's history buffer size is 4KB.
+//
+// Inflate stops at the end of the final block of the deflate stream.
+// If the underlying reader implements Peek and Discard like *bufio.Reader,
+// the bytes after the stream are left unread in the reader.
+// Otherwise, like flate.NewReader, an io.ByteReader is read byte by byte by ReadByte,
+// and the other readers are wrapped by a *bufio.Reader.
+// As the device decompresses the input chunk by chunk, Inflate may read more data than necessary from them,
+// the bytes read ahead can be retrieved by Remainder.
 type Inflate struct {
 	ctx           *device.Context
 	busyPoll      bool
@@ -30,9 +39,21 @@ type Inflate struct {
 	aecsPair      *[2]iaa.DecompressAECS
 	toggle        uint8
 	state         streamState
-	r             io.Reader
+	peek          peeker        // the underlying reader, or br or bytes if it doesn't support peeking
+	br            *bufio.Reader // wraps the underlying reader not supporting peeking nor ReadByte
+	bytes         *byteReader   // wraps the underlying io.ByteReader not supporting peeking
+	consumed      int64         // bytes of the deflate stream consumed
 	finished      bool
 	outputRemnant []byte
+	dict          []byte // preset dictionary, only the last 4KB is kept
+	dictBuf       []byte
+
+	writeBuf []byte // output buffer of WriteTo
+
+	limits      limits
+	inputTotal  int64 // bytes consumed, counted for the limits
+	outputTotal int64 // bytes returned, counted for the limits
+	limitErr    error
 }
 
 // NewInflate creates a new Inflate with 4KB buffer size to decompress data from reader r.
@@ -51,31 +72,156 @@ func NewInflateWithBufferSize(r io.Reader, bufferSize int, opts ...Option) (*Inf
 	if bufferSize < minBufferSize {
 		return nil, errors.BufferSizeTooSmall
 	}
-	opt := &option{}
-	for _, f := range opts {
-		f(opt)
+	opt := newOption(opts)
+	if opt.err != nil {
+		return nil, opt.err
 	}
 	i := &Inflate{}
 	i.busyPoll = opt.busyPoll
+	i.limits = opt.limits
 	i.ctx = iaa.LoadContext()
 	if i.ctx == nil {
 		return nil, errors.NoHardwareDeviceDetected
 	}
 	i.cr = mem.Alloc64Align[iaa.CompletionRecord]()
 	i.aecsPair = mem.Alloc64Align[[2]iaa.DecompressAECS]()
-	i.r = r
 	i.buffer = mem.Alloc64ByteAligned(uintptr(bufferSize))
+	i.setReader(r)
 	return i, nil
 }
 
+// peeker is implemented by buffered readers like *bufio.Reader,
+// Inflate only discards the bytes consumed from it.
+type peeker interface {
+	Peek(n int) ([]byte, error)
+	Discard(n int) (int, error)
+}
+
 // Reset reset the Inflate object
 func (i *Inflate) Reset(r io.Reader) {
+	i.resetStream(r)
+	i.resetLimits()
+}
+
+// resetStream resets the Inflate to decompress a new stream, the sizes counted for the limits are kept.
+func (i *Inflate) resetStream(r io.Reader) {
 	i.remnant = 0
 	i.toggle = 0
 	i.state = first
-	i.r = r
+	i.setReader(r)
+	i.consumed = 0
 	i.finished = false
 	i.outputRemnant = i.outputRemnant[:0]
+	i.dict = nil
+}
+
+// setReader sets the underlying reader, `r` is wrapped if it doesn't support peeking:
+// an io.ByteReader is wrapped by a reused *byteReader, and the other readers by a reused *bufio.Reader.
+func (i *Inflate) setReader(r io.Reader) {
+	var ok bool
+	if i.peek, ok = r.(peeker); ok || r == nil {
+		return
+	}
+	if b, ok := r.(io.ByteReader); ok {
+		if i.bytes == nil {
+			i.bytes = &byteReader{}
+		}
+		i.bytes.reset(b)
+		i.peek = i.bytes
+		return
+	}
+	if i.br == nil || i.br.Size() < len(i.buffer) {
+		i.br = bufio.NewReaderSize(r, len(i.buffer))
+	} else {
+		i.br.Reset(r)
+	}
+	i.peek = i.br
+}
+
+// Consumed returns the number of bytes of the deflate stream consumed from the underlying reader.
+func (i *Inflate) Consumed() int64 {
+	return i.consumed
+}
+
+// Remainder returns the bytes read from the underlying reader after the end of the deflate stream,
+// it's valid after Read returns io.EOF and until the next Reset.
+// The remainder is always empty if the underlying reader supports Peek and Discard.
+func (i *Inflate) Remainder() []byte {
+	if !i.finished {
+		return nil
+	}
+	switch {
+	case i.br != nil && i.peek == i.br:
+		data, _ := i.br.Peek(i.br.Buffered())
+		return data
+	case i.bytes != nil && i.peek == i.bytes:
+		return i.bytes.buf[i.bytes.off:]
+	}
+	return nil
+}
+
+// byteReader implements peeker for an io.ByteReader, the bytes are read one by one by ReadByte.
+type byteReader struct {
+	r   io.ByteReader
+	buf []byte
+	off int // bytes of buf discarded
+	err error
+}
+
+func (b *byteReader) reset(r io.ByteReader) {
+	b.r = r
+	b.buf = b.buf[:0]
+	b.off = 0
+	b.err = nil
+}
+
+// Peek returns the next n bytes, it returns fewer bytes only if ReadByte fails.
+func (b *byteReader) Peek(n int) ([]byte, error) {
+	if b.off != 0 {
+		b.buf = b.buf[:copy(b.buf, b.buf[b.off:])]
+		b.off = 0
+	}
+	for len(b.buf) < n && b.err == nil {
+		var c byte
+		if c, b.err = b.r.ReadByte(); b.err == nil {
+			b.buf = append(b.buf, c)
+		}
+	}
+	if len(b.buf) < n {
+		return b.buf, b.err
+	}
+	return b.buf[:n], nil
+}
+
+// Discard skips the next n bytes, which must have been peeked.
+func (b *byteReader) Discard(n int) (int, error) {
+	b.off += n
+	return n, nil
+}
+
+// fill reads the next input from the underlying reader into buffer.
+func (i *Inflate) fill() (err error) {
+	if i.peek == nil {
+		return io.ErrUnexpectedEOF
+	}
+	data, err := i.peek.Peek(len(i.buffer))
+	i.remnant = copy(i.buffer, data)
+	if err == bufio.ErrBufferFull && i.remnant != 0 {
+		err = nil
+	}
+	if err == io.EOF {
+		i.state = last
+		return nil
+	}
+	return err
+}
+
+// consume marks the first n bytes of the input consumed.
+func (i *Inflate) consume(n int) (err error) {
+	i.consumed += int64(n)
+	i.inputTotal += int64(n)
+	_, err = i.peek.Discard(n)
+	return err
 }
 
 func (i *Inflate) submit() iaa.StatusCode {
@@ -92,9 +238,13 @@ func (i *Inflate) DecompressAll(compressed []byte, raw []byte) (int, error) {
 	if len(compressed) > int(i.ctx.MaxTransferSize()) || len(raw) > int(i.ctx.MaxTransferSize()) {
 		return 0, errors.DataSizeTooLarge
 	}
+	raw, limitErr := i.limitDecompressAll(compressed, raw)
 	i.decompressJob(compressed, raw, &i.aecsPair[0])
 	status := i.submit()
 	if status != iaa.Success {
+		if status == iaa.OutputBufferOverflow && limitErr != nil {
+			return 0, limitErr
+		}
 		return 0, i.cr.CheckError()
 	}
 	runtime.KeepAlive(compressed)
@@ -103,7 +253,72 @@ func (i *Inflate) DecompressAll(compressed []byte, raw []byte) (int, error) {
 }
 
 // Read decompressed data from the underlying compressed reader.
+// An *errors.LimitError is returned if any decompression limit is exceeded, see MaxOutputSize.
 func (i *Inflate) Read(data []byte) (n int, err error) {
+	if i.limits == (limits{}) {
+		return i.read(data)
+	}
+	if i.limitErr != nil {
+		return 0, i.limitErr
+	}
+	n, err = i.read(i.limitRead(data))
+	if err != nil {
+		return n, err
+	}
+	return i.checkRead(n)
+}
+
+// inflateWriteSize is the size of the output buffer of WriteTo.
+const inflateWriteSize = 256 * 1024
+
+// WriteTo decompresses the data from the underlying reader into large aligned buffers and writes them to `w`,
+// until the end of the stream or an error occurs. It implements io.WriterTo, so io.Copy uses it.
+func (i *Inflate) WriteTo(w io.Writer) (n int64, err error) {
+	if i.writeBuf == nil {
+		i.writeBuf = mem.Alloc64ByteAligned(inflateWriteSize)
+	}
+	for {
+		// flush the buffer before it's too small to hold the output of a block
+		size, rerr := i.readAtLeast(i.writeBuf, len(i.writeBuf)-defaultBlockSize)
+		if size > 0 {
+			written, werr := w.Write(i.writeBuf[:size])
+			n += int64(written)
+			if werr != nil {
+				return n, werr
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
+// DecompressInto decompresses the data from the underlying reader into `dst` until `dst` is full,
+// the data is decompressed by the device into `dst` directly.
+// It returns io.EOF if the stream ends before `dst` is full, n is the size of the data decompressed.
+//
+// The `dst` should be aligned to a multiple of 64 bytes for the best performance, see mem.Alloc64ByteAligned.
+func (i *Inflate) DecompressInto(dst []byte) (n int, err error) {
+	return i.readAtLeast(dst, len(dst))
+}
+
+// readAtLeast reads into data until at least `min` bytes are read or an error occurs.
+func (i *Inflate) readAtLeast(data []byte, min int) (n int, err error) {
+	for n < min {
+		var m int
+		m, err = i.Read(data[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (i *Inflate) read(data []byte) (n int, err error) {
 	if len(i.outputRemnant) != 0 {
 		n = copy(data, i.outputRemnant)
 		if n == len(i.outputRemnant) {
@@ -118,11 +333,15 @@ func (i *Inflate) Read(data []byte) (n int, err error) {
 	if i.finished {
 		return 0, io.EOF
 	}
+	if i.dict != nil && i.state == first {
+		err = i.primeDictionary()
+		if err != nil {
+			return 0, err
+		}
+	}
 	if i.remnant == 0 && i.state != last {
-		i.remnant, err = i.r.Read(i.buffer)
-		if err == io.EOF {
-			i.state = last
-		} else if err != nil {
+		err = i.fill()
+		if err != nil {
 			return 0, err
 		}
 	}
@@ -148,14 +367,28 @@ func (i *Inflate) Read(data []byte) (n int, err error) {
 RETRY:
 	switch status {
 	case iaa.Success:
+		size := len(input)
+		if i.stoppedOnFinalEOB() {
+			// the bytes after the final block are not consumed
+			size = int(i.cr.Header.BytesCompleted)
+			i.finished = true
+		}
 		i.remnant = 0
 		if i.state == last {
 			i.finished = true
 		}
+		if err = i.consume(size); err != nil {
+			return 0, err
+		}
 	case iaa.OutputBufferOverflow:
-		i.remnant = len(input) - int(i.cr.Header.BytesCompleted)
-		temp := append(make([]byte, 0, i.remnant), input[i.cr.Header.BytesCompleted:]...)
+		completed := int(i.cr.Header.BytesCompleted)
+		if err = i.consume(completed); err != nil {
+			return 0, err
+		}
+		i.remnant = len(input) - completed
+		temp := append(make([]byte, 0, i.remnant), input[completed:]...)
 		copy(i.buffer, temp)
+		input = i.buffer[:i.remnant]
 		if i.cr.OutputSize == 0 {
 			// TODO: we should fix this problem!
 			if i.outputRemnant == nil {
@@ -163,7 +396,7 @@ RETRY:
 			} else {
 				i.outputRemnant = i.outputRemnant[:258]
 			}
-			i.decompressJob(i.buffer[:i.remnant], i.outputRemnant, &i.aecsPair[0])
+			i.decompressJob(input, i.outputRemnant, &i.aecsPair[0])
 			i.submit()
 			size := copy(data, i.outputRemnant[:i.cr.OutputSize])
 			copy(i.outputRemnant, i.outputRemnant[size:])
@@ -188,6 +421,14 @@ RETRY:
 	return int(outsize), nil
 }
 
+// stoppedOnFinalEOB reports whether the successful job stopped at the EOB of the final block.
+// With DecompressionFlagStopOnEOB and DecompressionFlagSelectBFinalEOB the device only stops at the final EOB,
+// and reports the input bytes consumed until it, even if the EOB is at the end of the input;
+// the bytes completed is zero if the whole input is decompressed without reaching the final EOB.
+func (i *Inflate) stoppedOnFinalEOB() bool {
+	return i.cr.Header.BytesCompleted != 0
+}
+
 type streamState uint8
 
 const (
@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/intel/ixl-go/internal/testutil"
+	"github.com/intel/ixl-go/util/mem"
 )
 
 func TestInflate(t *testing.T) {
@@ -153,6 +154,53 @@ func TestInflate_Binary(t *testing.T) {
 	}
 }
 
+func TestInflate_WriteTo(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(1024 * 1024))
+	d, err := NewDeflate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := d.CompressAll(nil, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewInflate(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.NewBuffer(nil)
+	n, err := io.Copy(buf, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(input)) || !bytes.Equal(buf.Bytes(), input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	r.Reset(bytes.NewReader(compressed))
+	dst := mem.Alloc64ByteAligned(300 * 1024)
+	var output []byte
+	for {
+		n, err := r.DecompressInto(dst)
+		output = append(output, dst[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(dst) {
+			t.Fatalf("expected full buffer, got %d bytes", n)
+		}
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
+
 func BenchmarkInflate(b *testing.B) {
 	if !Ready() {
 		b.Skip("IAA devices not found")
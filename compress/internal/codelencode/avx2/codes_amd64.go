@@ -1,6 +1,8 @@
 // Copyright (c) 2023, Intel Corporation.
 // SPDX-License-Identifier: BSD-3-Clause
 
+//go:build !purego
+
 // Package avx2 provides avx2 version PrepareForCodeLenCode
 package avx2
 
@@ -1,6 +1,8 @@
 // Copyright (c) 2023, Intel Corporation.
 // SPDX-License-Identifier: BSD-3-Clause
 
+//go:build !purego
+
 // Package avx512 provides avx512 version PrepareForCodeLenCode
 package avx512
 
@@ -1,4 +1,5 @@
-//+build !noasm !appengine
+//go:build !purego
+
 // AUTO-GENERATED BY C2GOASM -- DO NOT EDIT
 
 TEXT ·_prepareForCodeLenCode(SB), $32-32
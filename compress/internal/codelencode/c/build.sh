@@ -15,4 +15,7 @@ c2goasm -a -f ./avx2.s ../avx2/codes_amd64.s
 clang $ARGS -msse -msse2 -mavx2 -mavx512f -o avx512.s ./codes.c 
 c2goasm -a -f ./avx512.s ../avx512/codes_amd64.s
 
+# the assembly is excluded by the purego build tag
+sed -i '1s#^//+build .*#//go:build !purego#' ../sse2/codes_amd64.s ../avx2/codes_amd64.s ../avx512/codes_amd64.s
+
 rm ./avx2.s ./avx512.s ./sse2.s
\ No newline at end of file
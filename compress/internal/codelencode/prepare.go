@@ -8,8 +8,10 @@ import (
 	"github.com/intel/ixl-go/internal/iaa"
 )
 
-// Prepare for code len code
-var Prepare func(histogram *iaa.Histogram, source []byte) (litNum uint16, distanceNum uint16)
+// Prepare for code len code.
+// The fastest implementation supported by the CPU is selected at runtime,
+// the pure Go implementation is used on the other architectures or with the purego build tag.
+var Prepare = prepare
 
 func prepare(histogram *iaa.Histogram, source []byte) (litNum uint16, distanceNum uint16) {
 	litNum = 0
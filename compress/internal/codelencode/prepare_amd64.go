@@ -0,0 +1,24 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !purego
+
+package codelencode
+
+import (
+	"github.com/intel/ixl-go/compress/internal/codelencode/avx2"
+	"github.com/intel/ixl-go/compress/internal/codelencode/avx512"
+	"github.com/intel/ixl-go/compress/internal/codelencode/sse2"
+	"github.com/intel/ixl-go/internal/cpu"
+)
+
+func init() {
+	switch {
+	case cpu.X86.HasAVX512F:
+		Prepare = avx512.PrepareForCodeLenCode
+	case cpu.X86.HasAVX2:
+		Prepare = avx2.PrepareForCodeLenCode
+	case cpu.X86.HasSSE2:
+		Prepare = sse2.PrepareForCodeLenCode
+	}
+}
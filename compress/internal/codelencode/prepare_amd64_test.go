@@ -0,0 +1,69 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !purego
+
+package codelencode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/intel/ixl-go/compress/internal/codelencode/avx2"
+	"github.com/intel/ixl-go/compress/internal/codelencode/avx512"
+	"github.com/intel/ixl-go/compress/internal/codelencode/sse2"
+	"github.com/intel/ixl-go/internal/cpu"
+	"github.com/intel/ixl-go/internal/iaa"
+)
+
+var asmImpls = []struct {
+	name      string
+	supported bool
+	prepare   func(h *iaa.Histogram, dest []byte) (uint16, uint16)
+}{
+	{"sse2_asm", cpu.X86.HasSSE2, sse2.PrepareForCodeLenCode},
+	{"avx2_asm", cpu.X86.HasAVX2, avx2.PrepareForCodeLenCode},
+	{"avx512_asm", cpu.X86.HasAVX512F, avx512.PrepareForCodeLenCode},
+}
+
+func TestPrepareCodeLenCodeConsistentASM(t *testing.T) {
+	for _, impl := range asmImpls {
+		t.Run(impl.name, func(t *testing.T) {
+			if !impl.supported {
+				t.Skip("not supported by the CPU")
+			}
+			for i := 0; i < 100; i++ {
+				h := randHistogram()
+				data := make([]uint8, 288+32)
+				l, d := prepare(&h, data)
+
+				data2 := make([]uint8, 288+32)
+				l2, d2 := impl.prepare(&h, data2)
+				if !bytes.Equal(data2, data) || l2 != l || d2 != d {
+					t.Fatalf("%s function is inconsistent with go function", impl.name)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPrepareCodeLenCode(b *testing.B) {
+	h := randHistogram()
+	data := make([]uint8, 288+32)
+	for _, impl := range asmImpls {
+		if !impl.supported {
+			continue
+		}
+		prepare := impl.prepare
+		b.Run(impl.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				prepare(&h, data)
+			}
+		})
+	}
+	b.Run("go", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			prepare(&h, data)
+		}
+	})
+}
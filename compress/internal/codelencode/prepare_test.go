@@ -4,7 +4,9 @@
 package codelencode
 
 import (
+	"bytes"
 	"math/rand"
+	"testing"
 
 	"github.com/intel/ixl-go/internal/iaa"
 )
@@ -23,3 +25,17 @@ func randHistogram() iaa.Histogram {
 	}
 	return h
 }
+
+func TestPrepare(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		h := randHistogram()
+		data := make([]uint8, 288+32)
+		l, d := prepare(&h, data)
+
+		data2 := make([]uint8, 288+32)
+		l2, d2 := Prepare(&h, data2)
+		if !bytes.Equal(data2, data) || l2 != l || d2 != d {
+			t.Fatal("selected function is inconsistent with go function")
+		}
+	}
+}
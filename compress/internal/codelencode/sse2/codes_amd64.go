@@ -1,6 +1,8 @@
 // Copyright (c) 2023, Intel Corporation.
 // SPDX-License-Identifier: BSD-3-Clause
 
+//go:build !purego
+
 // Package sse2 provides sse2 version PrepareForCodeLenCode
 package sse2
 
@@ -1,4 +1,5 @@
-//+build !noasm !appengine
+//go:build !purego
+
 // AUTO-GENERATED BY C2GOASM -- DO NOT EDIT
 
 DATA LCDATA1<>+0x000(SB)/8, $0x000000ff000000ff
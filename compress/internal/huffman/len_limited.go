@@ -27,8 +27,8 @@ func (l *LenLimitedCode) Generate(limitedLen int, histogram []int32, codeLens []
 		if v != 0 {
 			num++
 			l.counts = append(l.counts, litCount{
-				lit:   uint16(i),
-				count: uint16(v),
+				lit:   uint32(i),
+				count: uint32(v),
 			})
 		}
 	}
@@ -38,7 +38,7 @@ func (l *LenLimitedCode) Generate(limitedLen int, histogram []int32, codeLens []
 
 	sortDecLitCounts(l.counts)
 
-	if l.w == nil {
+	if cap(l.w) < len(l.counts) {
 		l.w = make([]int32, len(l.counts), len(histogram))
 	} else {
 		l.w = l.w[:len(l.counts)]
@@ -43,6 +43,32 @@ func TestLenLimitedCode(t *testing.T) {
 	}
 }
 
+func TestLenLimitedCode_LargeCounts(t *testing.T) {
+	// the counts of the blocks larger than 64KB don't fit in 16 bits
+	llc := NewLenLimitedCode()
+	hist := []int32{1 << 20, 1 << 16, 0, 1, 1}
+	llc.Generate(15, hist, hist)
+	if !equals(hist, []int32{1, 2, 0, 3, 3}) {
+		t.Fatal("unexpected code lengths", hist)
+	}
+
+	// the code lengths don't change if all the counts are scaled
+	for _, data := range lenLimitedTests {
+		expected := make([]int32, len(data.hist))
+		llc.Generate(int(data.originMaxLen-3), data.hist, expected)
+		scaled := make([]int32, len(data.hist))
+		for i, v := range data.hist {
+			scaled[i] = v << 12
+		}
+		llc.Generate(int(data.originMaxLen-3), scaled, scaled)
+		if !equals(scaled, expected) {
+			t.Log(scaled)
+			t.Log(expected)
+			t.Fatal("the code lengths of the scaled histogram are different")
+		}
+	}
+}
+
 func equals[N number](a, b []N) bool {
 	if len(a) != len(b) {
 		return false
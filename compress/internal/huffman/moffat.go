@@ -7,9 +7,11 @@ package huffman
 // Check http://hjemmesider.diku.dk/~jyrki/Paper/WADS95.pdf .
 type MoffatHuffmanCode struct{}
 
+// litCount is sorted as a uint64, the count is in the higher 32 bits.
+// The count is 32 bits, since the blocks may be larger than 64KB.
 type litCount struct {
-	lit   uint16
-	count uint16
+	lit   uint32
+	count uint32
 }
 
 type decLitCounts []litCount
@@ -101,8 +103,8 @@ func (m *MoffatHuffmanCode) Generate(histogram []int32) {
 	for i, v := range histogram {
 		if v != 0 {
 			counts = append(counts, litCount{
-				lit:   uint16(i),
-				count: uint16(v),
+				lit:   uint32(i),
+				count: uint32(v),
 			})
 		}
 	}
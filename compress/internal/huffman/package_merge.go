@@ -0,0 +1,120 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package huffman
+
+import "sort"
+
+// PackageMergeCode implements the package-merge algorithm generating optimal length-limited huffman codes.
+// Check https://en.wikipedia.org/wiki/Package-merge_algorithm .
+//
+// Unlike LenLimitedCode, the code lengths are optimal (the total size of the encoded symbols is minimal)
+// under the length limit, even for skewed histograms.
+type PackageMergeCode struct {
+	leaves    weightedSymbols
+	unlimited []int32 // the weights sorted in descending order, then the unlimited code lengths
+	weights   []int64 // the weights of the items of the current list
+	next      []int64
+	isLeaf    []bool // the kinds of the items of all lists, from the level maxLen to the level 1
+	offsets   []int  // the start of every list in isLeaf
+}
+
+// weightedSymbols are the symbols sorted by weight, every item is `weight << 16 | symbol`.
+type weightedSymbols []uint64
+
+func (w *weightedSymbols) Len() int           { return len(*w) }
+func (w *weightedSymbols) Less(i, j int) bool { return (*w)[i] < (*w)[j] }
+func (w *weightedSymbols) Swap(i, j int)      { (*w)[i], (*w)[j] = (*w)[j], (*w)[i] }
+
+// NewPackageMergeCode creates a new PackageMergeCode instance.
+func NewPackageMergeCode() *PackageMergeCode {
+	return &PackageMergeCode{}
+}
+
+// Generate huffman tree from histogram and write tree codes into codes.
+// The histogram and codeLens can be the same slice.
+//
+// The number of the used symbols must not be larger than 2^maxLen.
+func (p *PackageMergeCode) Generate(maxLen int, histogram []int32, codeLens []int32) (num int) {
+	p.leaves = p.leaves[:0]
+	for i, v := range histogram {
+		if v != 0 {
+			p.leaves = append(p.leaves, uint64(v)<<16|uint64(i))
+		}
+	}
+	for i := range codeLens {
+		codeLens[i] = 0
+	}
+	num = len(p.leaves)
+	switch num {
+	case 0:
+		return 0
+	case 1:
+		codeLens[p.leaves[0]&0xffff] = 1
+		return 1
+	}
+	sort.Sort(&p.leaves)
+
+	// the unlimited huffman code is optimal if it doesn't exceed the limit
+	p.unlimited = p.unlimited[:0]
+	for i := num - 1; i >= 0; i-- {
+		p.unlimited = append(p.unlimited, int32(p.leaves[i]>>16))
+	}
+	if (&MoffatHuffmanCode{}).codeLens(p.unlimited) <= int32(maxLen) {
+		for i, l := range p.unlimited {
+			codeLens[p.leaves[num-1-i]&0xffff] = l
+		}
+		return num
+	}
+
+	// the list of the level maxLen contains the leaves only
+	p.weights = p.weights[:0]
+	p.isLeaf = p.isLeaf[:0]
+	p.offsets = append(p.offsets[:0], 0)
+	for _, v := range p.leaves {
+		p.weights = append(p.weights, int64(v>>16))
+		p.isLeaf = append(p.isLeaf, true)
+	}
+	// only the first 2n-2 items of the list of the level 1 are selected,
+	// the items after them in any list can't be selected.
+	selected := 2*num - 2
+	for level := maxLen - 1; level >= 1; level-- {
+		p.offsets = append(p.offsets, len(p.isLeaf))
+		p.next = p.next[:0]
+		leaf := 0
+		// merge the leaves and the packages of every two items of the previous list
+		for k := 0; k+1 < len(p.weights) && len(p.next) < selected; k += 2 {
+			weight := p.weights[k] + p.weights[k+1]
+			for ; leaf < num && int64(p.leaves[leaf]>>16) <= weight && len(p.next) < selected; leaf++ {
+				p.next = append(p.next, int64(p.leaves[leaf]>>16))
+				p.isLeaf = append(p.isLeaf, true)
+			}
+			p.next = append(p.next, weight)
+			p.isLeaf = append(p.isLeaf, false)
+		}
+		for ; leaf < num && len(p.next) < selected; leaf++ {
+			p.next = append(p.next, int64(p.leaves[leaf]>>16))
+			p.isLeaf = append(p.isLeaf, true)
+		}
+		p.weights, p.next = p.next, p.weights
+	}
+	p.offsets = append(p.offsets, len(p.isLeaf))
+
+	// The selected items of every list are always a prefix of the list,
+	// and the leaves in the prefix are the symbols of the smallest weights.
+	// The code length of a symbol is the number of its leaves in the selected items of all lists.
+	for j := len(p.offsets) - 2; j >= 0 && selected > 0; j-- {
+		leaves := 0
+		for _, isLeaf := range p.isLeaf[p.offsets[j] : p.offsets[j]+selected] {
+			if isLeaf {
+				leaves++
+			}
+		}
+		for _, v := range p.leaves[:leaves] {
+			codeLens[v&0xffff]++
+		}
+		// every selected package selects two items of the previous list
+		selected = 2 * (selected - leaves)
+	}
+	return num
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package huffman
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func cost(histogram, lens []int32) int64 {
+	var c int64
+	for i, v := range histogram {
+		c += int64(v) * int64(lens[i])
+	}
+	return c
+}
+
+// bruteForceCost returns the minimal cost of the prefix codes whose lengths are not larger than maxLen.
+func bruteForceCost(weights []int32, maxLen int) int64 {
+	best := int64(-1)
+	lens := make([]int32, len(weights))
+	var search func(i int, kraft int)
+	search = func(i int, kraft int) {
+		// kraft is sum(2^(maxLen-len)) of the assigned lengths
+		if kraft > 1<<maxLen {
+			return
+		}
+		if i == len(weights) {
+			if c := cost(weights, lens); best < 0 || c < best {
+				best = c
+			}
+			return
+		}
+		for l := 1; l <= maxLen; l++ {
+			lens[i] = int32(l)
+			search(i+1, kraft+1<<(maxLen-l))
+		}
+	}
+	search(0, 0)
+	return best
+}
+
+func TestPackageMergeCode_BruteForce(t *testing.T) {
+	pm := NewPackageMergeCode()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		n := 2 + rng.Intn(6)
+		weights := make([]int32, n)
+		for j := range weights {
+			if rng.Intn(2) == 0 {
+				// skewed
+				weights[j] = 1 << rng.Intn(16)
+			} else {
+				weights[j] = 1 + rng.Int31n(100)
+			}
+		}
+		minLen := 1
+		for 1<<minLen < n {
+			minLen++
+		}
+		for maxLen := minLen; maxLen <= n; maxLen++ {
+			lens := make([]int32, n)
+			if num := pm.Generate(maxLen, weights, lens); num != n {
+				t.Fatalf("expected %d symbols, got %d", n, num)
+			}
+			if max(lens) > int32(maxLen) || !validCodes(lens) {
+				t.Fatalf("invalid code lengths %v for %v, limit %d", lens, weights, maxLen)
+			}
+			if c, expected := cost(weights, lens), bruteForceCost(weights, maxLen); c != expected {
+				t.Fatalf("expected cost %d, got %d: lengths %v for %v, limit %d", expected, c, lens, weights, maxLen)
+			}
+		}
+	}
+}
+
+func TestPackageMergeCode(t *testing.T) {
+	pm := NewPackageMergeCode()
+	llc := NewLenLimitedCode()
+
+	// zero and one symbol, in place
+	hist := []int32{0, 0, 0}
+	if pm.Generate(7, hist, hist) != 0 || max(hist) != 0 {
+		t.Fatal("unexpected lengths", hist)
+	}
+	hist = []int32{0, 10, 0}
+	if pm.Generate(7, hist, hist) != 1 || hist[1] != 1 {
+		t.Fatal("unexpected lengths", hist)
+	}
+
+	for _, data := range lenLimitedTests {
+		// the same cost as the unlimited huffman code if the limit is not reached
+		unlimited := make([]int32, len(data.hist))
+		copy(unlimited, data.hist)
+		(&MoffatHuffmanCode{}).Generate(unlimited)
+		lens := make([]int32, len(data.hist))
+		pm.Generate(int(data.originMaxLen), data.hist, lens)
+		if cost(data.hist, lens) != cost(data.hist, unlimited) || !validCodes(lens) {
+			t.Fatal("the code is not optimal", lens)
+		}
+
+		// not worse than LenLimitedCode
+		limit := int(data.originMaxLen - 3)
+		pm.Generate(limit, data.hist, lens)
+		heuristic := make([]int32, len(data.hist))
+		copy(heuristic, data.hist)
+		llc.Generate(limit, heuristic, heuristic)
+		if max(lens) > int32(limit) || !validCodes(lens) {
+			t.Fatal("invalid code lengths", lens)
+		}
+		if cost(data.hist, lens) > cost(data.hist, heuristic) {
+			t.Fatalf("cost %d is larger than LenLimitedCode %d", cost(data.hist, lens), cost(data.hist, heuristic))
+		}
+	}
+
+	// skewed histogram of the code length alphabet, limited to 7 bits
+	hist = make([]int32, 19)
+	for i := range hist {
+		hist[i] = 1 << i / 8
+	}
+	hist[0] = 1
+	lens := make([]int32, len(hist))
+	pm.Generate(7, hist, lens)
+	heuristic := make([]int32, len(hist))
+	copy(heuristic, hist)
+	llc.Generate(7, heuristic, heuristic)
+	if max(lens) > 7 || !validCodes(lens) {
+		t.Fatal("invalid code lengths", lens)
+	}
+	if cost(hist, lens) >= cost(hist, heuristic) {
+		t.Fatalf("cost %d is not smaller than LenLimitedCode %d", cost(hist, lens), cost(hist, heuristic))
+	}
+}
+
+func BenchmarkPackageMergeCode(b *testing.B) {
+	pm := NewPackageMergeCode()
+	hist := make([]int32, 286)
+	lens := make([]int32, len(hist))
+	rng := rand.New(rand.NewSource(1))
+	for i := range hist {
+		hist[i] = rng.Int31n(1000)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pm.Generate(15, hist, lens)
+	}
+}
+
+func BenchmarkLenLimitedCode(b *testing.B) {
+	llc := NewLenLimitedCode()
+	hist := make([]int32, 286)
+	lens := make([]int32, len(hist))
+	rng := rand.New(rand.NewSource(1))
+	for i := range hist {
+		hist[i] = rng.Int31n(1000)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		llc.Generate(15, hist, lens)
+	}
+}
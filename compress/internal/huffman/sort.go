@@ -5,7 +5,7 @@ package huffman
 
 import "unsafe"
 
-func quickSort(arr []uint32, left int, right int) {
+func quickSort[T uint32 | uint64](arr []T, left int, right int) {
 	for left < right {
 		if right-left < 16 {
 			insertSort(arr[left : right+1])
@@ -40,7 +40,7 @@ func quickSort(arr []uint32, left int, right int) {
 	}
 }
 
-func insertSort(arr []uint32) {
+func insertSort[T uint32 | uint64](arr []T) {
 	for i := 1; i < len(arr); i++ {
 		for j := i; j > 0 && arr[j-1] > arr[j]; j-- {
 			arr[j-1], arr[j] = arr[j], arr[j-1]
@@ -48,7 +48,7 @@ func insertSort(arr []uint32) {
 	}
 }
 
-func quickSortReverse(arr []uint32, left int, right int) {
+func quickSortReverse[T uint32 | uint64](arr []T, left int, right int) {
 	for left < right {
 		if right-left < 16 {
 			insertReverseSort(arr[left : right+1])
@@ -83,7 +83,7 @@ func quickSortReverse(arr []uint32, left int, right int) {
 	}
 }
 
-func insertReverseSort(arr []uint32) {
+func insertReverseSort[T uint32 | uint64](arr []T) {
 	for i := 1; i < len(arr); i++ {
 		for j := i; j > 0 && arr[j-1] < arr[j]; j-- {
 			arr[j-1], arr[j] = arr[j], arr[j-1]
@@ -92,6 +92,6 @@ func insertReverseSort(arr []uint32) {
 }
 
 func sortDecLitCounts(arr decLitCounts) {
-	ilc := *(*[]uint32)(unsafe.Pointer(&arr))
+	ilc := *(*[]uint64)(unsafe.Pointer(&arr))
 	quickSortReverse(ilc, 0, len(arr)-1)
 }
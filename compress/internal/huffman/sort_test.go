@@ -36,7 +36,7 @@ func TestQuickSort(t *testing.T) {
 	arr := make([]uint32, 0)
 	quickSort(arr, 0, -1)
 
-	quickSort(nil, 0, -1)
+	quickSort[uint32](nil, 0, -1)
 }
 
 func TestSortDecLitCounts(t *testing.T) {
@@ -46,7 +46,7 @@ func TestSortDecLitCounts(t *testing.T) {
 		// generate random test data
 		size := rand.Intn(1024) + 1
 		for i := 0; i < size; i++ {
-			testData = append(testData, litCount{count: uint16(rand.Uint32())})
+			testData = append(testData, litCount{count: rand.Uint32()})
 		}
 		copied := make(decLitCounts, len(testData))
 		copy(copied, testData)
@@ -0,0 +1,409 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package zran decompresses deflate streams in software block by block,
+// and reports the bit offsets of the block boundaries and the 32KB history window,
+// which are the checkpoints of the seek index like zran.c of zlib.
+package zran
+
+import (
+	"io"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+// WindowSize is the size of the history window of deflate.
+const WindowSize = 32 * 1024
+
+// bufferSize is the size of the output buffer, the data before the window is written out when it's full.
+const bufferSize = 4 * WindowSize
+
+// maxMatch is the max length of a match.
+const maxMatch = 258
+
+// Decoder decompresses a deflate stream block by block.
+//
+// The bytes are read from the io.ByteReader one by one when they are needed,
+// so the reader is not read beyond the end of the stream.
+type Decoder struct {
+	w       io.Writer // receives the decompressed data
+	r       io.ByteReader
+	in      int64  // bytes read from r
+	bits    uint32 // bit buffer
+	nbits   uint
+	buf     []byte // the decompressed data, the window is kept at the end
+	flushed int    // the bytes of buf written to w
+
+	lit  huffman
+	dist huffman
+}
+
+// NewDecoder creates a new Decoder writing the decompressed data to `w`.
+func NewDecoder(w io.Writer) *Decoder {
+	return &Decoder{w: w, buf: make([]byte, 0, bufferSize)}
+}
+
+// Reset starts to decompress a new deflate stream from `r`, the window is cleared.
+func (d *Decoder) Reset(r io.ByteReader) {
+	d.r = r
+	d.in = 0
+	d.bits = 0
+	d.nbits = 0
+	d.buf = d.buf[:0]
+	d.flushed = 0
+}
+
+// Prime resumes the decompression from a checkpoint after Reset,
+// the low `nbits` bits of `bits` are decoded before the bytes of the reader,
+// and `window` is the history window before the checkpoint.
+func (d *Decoder) Prime(bits byte, nbits uint, window []byte) {
+	d.bits = uint32(bits) & (1<<nbits - 1)
+	d.nbits = nbits
+	d.buf = append(d.buf[:0], window...)
+	d.flushed = len(d.buf)
+}
+
+// Offset returns the position of the next block header,
+// `in` is the offset of the byte holding the first bit, and `bit` is the index of the bit in the byte.
+// The offset is relative to the position of the reader when Reset is called.
+func (d *Decoder) Offset() (in int64, bit uint8) {
+	consumed := d.in*8 - int64(d.nbits)
+	return consumed / 8, uint8(consumed % 8)
+}
+
+// InputSize returns the number of bytes read from the reader since Reset,
+// the bits of the last byte not used by the stream are dropped.
+func (d *Decoder) InputSize() int64 {
+	return d.in
+}
+
+// Window returns the history window (up to 32KB) at the current position,
+// it's valid until the next call of ReadBlock.
+func (d *Decoder) Window() []byte {
+	if len(d.buf) > WindowSize {
+		return d.buf[len(d.buf)-WindowSize:]
+	}
+	return d.buf
+}
+
+// ReadBlock decompresses the next block, the decompressed data has been written when it returns.
+// It returns true if the block is the final block of the stream.
+func (d *Decoder) ReadBlock() (final bool, err error) {
+	hdr, err := d.readBits(3)
+	if err != nil {
+		return false, err
+	}
+	final = hdr&1 == 1
+	switch hdr >> 1 {
+	case 0:
+		err = d.storedBlock()
+	case 1:
+		d.lit.init(fixedLiteralLengths[:])
+		d.dist.init(fixedDistanceLengths[:])
+		err = d.huffmanBlock()
+	case 2:
+		err = d.dynamicBlock()
+	default:
+		err = errors.ErrCorruptedData
+	}
+	if err != nil {
+		return false, err
+	}
+	return final, d.flush()
+}
+
+// flush writes the decompressed data to w, and keeps the window in the buffer.
+func (d *Decoder) flush() error {
+	if d.flushed < len(d.buf) {
+		_, err := d.w.Write(d.buf[d.flushed:])
+		if err != nil {
+			return err
+		}
+	}
+	if len(d.buf) > WindowSize {
+		copy(d.buf, d.buf[len(d.buf)-WindowSize:])
+		d.buf = d.buf[:WindowSize]
+	}
+	d.flushed = len(d.buf)
+	return nil
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	d.in++
+	return b, nil
+}
+
+// readBits reads `n` (not more than 16) bits, the bytes are read only when they are needed.
+func (d *Decoder) readBits(n uint) (uint32, error) {
+	for d.nbits < n {
+		b, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		d.bits |= uint32(b) << d.nbits
+		d.nbits += 8
+	}
+	v := d.bits & (1<<n - 1)
+	d.bits >>= n
+	d.nbits -= n
+	return v, nil
+}
+
+func (d *Decoder) storedBlock() error {
+	// skip the bits to the byte boundary
+	d.bits = 0
+	d.nbits = 0
+	var hdr [4]byte
+	for i := range hdr {
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		hdr[i] = b
+	}
+	size := uint16(hdr[0]) | uint16(hdr[1])<<8
+	if ^size != uint16(hdr[2])|uint16(hdr[3])<<8 {
+		return errors.ErrCorruptedData
+	}
+	for ; size > 0; size-- {
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		d.buf = append(d.buf, b)
+		if len(d.buf) == cap(d.buf) {
+			if err = d.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// codeLengthOrder is the order of the code length code lengths in the dynamic block header.
+var codeLengthOrder = [19]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+
+func (d *Decoder) dynamicBlock() error {
+	v, err := d.readBits(14)
+	if err != nil {
+		return err
+	}
+	nlit := int(v&0x1f) + 257
+	ndist := int(v>>5&0x1f) + 1
+	nclen := int(v>>10) + 4
+	if nlit > 286 || ndist > 30 {
+		return errors.ErrCorruptedData
+	}
+	var lengths [286 + 30]uint8
+	for i := 0; i < nclen; i++ {
+		l, err := d.readBits(3)
+		if err != nil {
+			return err
+		}
+		lengths[codeLengthOrder[i]] = uint8(l)
+	}
+	var clen huffman
+	if !clen.init(lengths[:19]) {
+		return errors.ErrCorruptedData
+	}
+	lengths = [286 + 30]uint8{}
+	for i := 0; i < nlit+ndist; {
+		sym, err := d.decode(&clen)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var prev uint8
+		var repeat uint32
+		switch sym {
+		case 16:
+			if i == 0 {
+				return errors.ErrCorruptedData
+			}
+			prev = lengths[i-1]
+			repeat, err = d.readBits(2)
+			repeat += 3
+		case 17:
+			repeat, err = d.readBits(3)
+			repeat += 3
+		default:
+			repeat, err = d.readBits(7)
+			repeat += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+int(repeat) > nlit+ndist {
+			return errors.ErrCorruptedData
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[i] = prev
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		// no end of block code
+		return errors.ErrCorruptedData
+	}
+	if !d.lit.init(lengths[:nlit]) || !d.dist.init(lengths[nlit:nlit+ndist]) {
+		return errors.ErrCorruptedData
+	}
+	return d.huffmanBlock()
+}
+
+var (
+	lengthBase   = [29]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lengthExtra  = [29]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distanceBase = [30]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769,
+		1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distanceExtra = [30]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+)
+
+// huffmanBlock decodes the symbols of a fixed or dynamic block until the end of block code.
+func (d *Decoder) huffmanBlock() error {
+	for {
+		sym, err := d.decode(&d.lit)
+		if err != nil {
+			return err
+		}
+		if sym < 256 {
+			d.buf = append(d.buf, byte(sym))
+		} else if sym == 256 {
+			return nil
+		} else {
+			sym -= 257
+			if sym >= 29 {
+				return errors.ErrCorruptedData
+			}
+			extra, err := d.readBits(uint(lengthExtra[sym]))
+			if err != nil {
+				return err
+			}
+			length := int(lengthBase[sym]) + int(extra)
+			sym, err = d.decode(&d.dist)
+			if err != nil {
+				return err
+			}
+			if sym >= 30 {
+				return errors.ErrCorruptedData
+			}
+			extra, err = d.readBits(uint(distanceExtra[sym]))
+			if err != nil {
+				return err
+			}
+			distance := int(distanceBase[sym]) + int(extra)
+			if distance > len(d.buf) {
+				return errors.ErrCorruptedData
+			}
+			// the bytes are copied one by one, the match may overlap itself
+			start := len(d.buf) - distance
+			for i := 0; i < length; i++ {
+				d.buf = append(d.buf, d.buf[start+i])
+			}
+		}
+		if len(d.buf) > cap(d.buf)-maxMatch {
+			if err = d.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decode decodes one symbol by the canonical huffman code.
+func (d *Decoder) decode(h *huffman) (int, error) {
+	code, first, index := 0, 0, 0
+	for length := 1; length <= h.max; length++ {
+		if d.nbits == 0 {
+			b, err := d.readByte()
+			if err != nil {
+				return 0, err
+			}
+			d.bits = uint32(b)
+			d.nbits = 8
+		}
+		code |= int(d.bits & 1)
+		d.bits >>= 1
+		d.nbits--
+		count := int(h.count[length])
+		if code-first < count {
+			return int(h.symbol[index+code-first]), nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, errors.ErrCorruptedData
+}
+
+// huffman is a canonical huffman code, decoded bit by bit like puff.c of zlib.
+type huffman struct {
+	count  [16]uint16  // number of the codes of each length
+	symbol [288]uint16 // symbols ordered by the codes
+	max    int         // the max code length, no bits are read by an empty code
+}
+
+// init builds the code from the code lengths, it returns false if the lengths are over-subscribed or incomplete.
+// Like compress/flate, an incomplete code is only accepted if it's empty or has a single code of length 1.
+func (h *huffman) init(lengths []uint8) bool {
+	h.count = [16]uint16{}
+	h.max = 0
+	for _, l := range lengths {
+		h.count[l]++
+		if int(l) > h.max {
+			h.max = int(l)
+		}
+	}
+	left := 1
+	for l := 1; l < len(h.count); l++ {
+		left = left<<1 - int(h.count[l])
+		if left < 0 {
+			return false
+		}
+	}
+	codes := len(lengths) - int(h.count[0])
+	if left != 0 && codes != 0 && !(codes == 1 && h.count[1] == 1) {
+		return false
+	}
+	var offsets [16]uint16
+	for l := 1; l < len(h.count)-1; l++ {
+		offsets[l+1] = offsets[l] + h.count[l]
+	}
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offsets[l]] = uint16(sym)
+			offsets[l]++
+		}
+	}
+	return true
+}
+
+// fixedDistanceLengths has 32 codes to be complete, the distance codes 30 and 31 are invalid.
+var fixedLiteralLengths, fixedDistanceLengths = func() (lit [288]uint8, dist [32]uint8) {
+	for i := range lit {
+		switch {
+		case i < 144:
+			lit[i] = 8
+		case i < 256:
+			lit[i] = 9
+		case i < 280:
+			lit[i] = 7
+		default:
+			lit[i] = 8
+		}
+	}
+	for i := range dist {
+		dist[i] = 5
+	}
+	return lit, dist
+}()
@@ -0,0 +1,132 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zran
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func deflate(t *testing.T, data []byte, level int) []byte {
+	buf := bytes.NewBuffer(nil)
+	w, err := flate.NewWriter(buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(data)
+	_ = w.Flush()
+	_, _ = w.Write(data[:len(data)/3])
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func TestDecoder(t *testing.T) {
+	random := make([]byte, 100*1024)
+	_, _ = rand.Read(random)
+	input := append([]byte(testutil.RandomText(500*1024)), random...)
+	expected := append(append([]byte{}, input...), input[:len(input)/3]...)
+	for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.DefaultCompression, flate.HuffmanOnly} {
+		compressed := deflate(t, input, level)
+		// the data after the stream must not be read
+		r := bytes.NewReader(append(compressed, "trailer"...))
+		out := bytes.NewBuffer(nil)
+		d := NewDecoder(out)
+		d.Reset(r)
+		blocks := 0
+		for {
+			final, err := d.ReadBlock()
+			if err != nil {
+				t.Fatalf("level %d: %v", level, err)
+			}
+			blocks++
+			window := d.Window()
+			if !bytes.Equal(window, out.Bytes()[out.Len()-len(window):]) {
+				t.Fatalf("level %d: unexpected window", level)
+			}
+			if final {
+				break
+			}
+		}
+		if !bytes.Equal(out.Bytes(), expected) {
+			t.Fatalf("level %d: decompressed data is not consistent with input", level)
+		}
+		if d.InputSize() != int64(len(compressed)) || r.Len() != len("trailer") {
+			t.Fatalf("level %d: unexpected input size %d, expected %d", level, d.InputSize(), len(compressed))
+		}
+		if blocks < 3 {
+			t.Fatalf("level %d: too few blocks %d", level, blocks)
+		}
+	}
+}
+
+func TestDecoder_Corrupted(t *testing.T) {
+	compressed := deflate(t, []byte(testutil.RandomText(10000)), flate.DefaultCompression)
+	d := NewDecoder(io.Discard)
+	d.Reset(bytes.NewReader(compressed[:len(compressed)/2]))
+	var err error
+	for err == nil {
+		_, err = d.ReadBlock()
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+	// BTYPE = 11 is reserved
+	d.Reset(bytes.NewReader([]byte{0b111}))
+	if _, err = d.ReadBlock(); err != errors.ErrCorruptedData {
+		t.Fatalf("expected corrupted data error, got %v", err)
+	}
+	// LEN and NLEN mismatch
+	d.Reset(bytes.NewReader([]byte{1, 1, 0, 0, 0}))
+	if _, err = d.ReadBlock(); err != errors.ErrCorruptedData {
+		t.Fatalf("expected corrupted data error, got %v", err)
+	}
+}
+
+// FuzzDecoder cross-checks the Decoder against compress/flate:
+// a stream is accepted by both or neither, and the decompressed data and the bytes consumed are the same.
+func FuzzDecoder(f *testing.F) {
+	f.Add([]byte{1, 1, 0, 0xfe, 0xff, 'a'})
+	f.Add([]byte{0b111})
+	for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.DefaultCompression, flate.HuffmanOnly} {
+		buf := bytes.NewBuffer(nil)
+		w, _ := flate.NewWriter(buf, level)
+		_, _ = w.Write([]byte(testutil.RandomText(2000)))
+		_ = w.Close()
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		expected, expectedErr := io.ReadAll(flate.NewReader(r))
+		consumed := len(data) - r.Len()
+
+		out := bytes.NewBuffer(nil)
+		d := NewDecoder(out)
+		d.Reset(bytes.NewReader(data))
+		var err error
+		for final := false; !final && err == nil; {
+			final, err = d.ReadBlock()
+		}
+		if (err == nil) != (expectedErr == nil) {
+			t.Fatalf("compress/flate returns %v, got %v", expectedErr, err)
+		}
+		if (err == io.ErrUnexpectedEOF) != (expectedErr == io.ErrUnexpectedEOF) {
+			t.Fatalf("compress/flate returns %v, got %v", expectedErr, err)
+		}
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(out.Bytes(), expected) {
+			t.Fatal("decompressed data is not consistent with compress/flate")
+		}
+		if d.InputSize() != int64(consumed) {
+			t.Fatalf("compress/flate consumes %d bytes, got %d", consumed, d.InputSize())
+		}
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"compress/flate"
+	"io"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+// Compression levels, they have the same values as the levels defined in compress/flate and compress/gzip.
+//
+// IAA has no tuning "button" like the software implementations,
+// so the levels are mapped onto the hardware modes:
+//
+//   - NoCompression: the data is written in stored blocks.
+//   - BestSpeed: the data is compressed using the fixed huffman code, no statistic pass is needed.
+//   - 2 ~ 9 and DefaultCompression: the data is compressed using the dynamic huffman code.
+//   - HuffmanOnlyLevel: the data is compressed using the dynamic huffman code without any LZ77 matching.
+const (
+	NoCompression      = flate.NoCompression
+	BestSpeed          = flate.BestSpeed
+	BestCompression    = flate.BestCompression
+	DefaultCompression = flate.DefaultCompression
+	HuffmanOnlyLevel   = flate.HuffmanOnly
+)
+
+func levelMode(level int) (deflateMode, error) {
+	switch {
+	case level == NoCompression:
+		return modeStored, nil
+	case level == BestSpeed:
+		return modeFixed, nil
+	case level == HuffmanOnlyLevel:
+		return modeHuffmanOnly, nil
+	case level == DefaultCompression || (level > BestSpeed && level <= BestCompression):
+		return modeDynamic, nil
+	}
+	return modeDynamic, errors.InvalidCompressionLevel
+}
+
+// Level sets the compression level, see the NoCompression for how the level is mapped.
+// The level will also be recorded into the XFL field of the gzip header.
+func Level(level int) Option {
+	return func(opt *option) {
+		mode, err := levelMode(level)
+		if err != nil {
+			opt.err = err
+			return
+		}
+		opt.mode = mode
+		opt.level = level
+	}
+}
+
+// NewDeflateWriterLevel is like NewDeflateWriter but specifies the compression level
+// instead of assuming DefaultCompression.
+func NewDeflateWriterLevel(w io.Writer, level int, opts ...Option) (*BufWriter, error) {
+	return NewDeflateWriter(w, append(opts[:len(opts):len(opts)], Level(level))...)
+}
+
+// NewGzipWriterLevel is like NewGzipWriter but specifies the compression level
+// instead of assuming DefaultCompression.
+func NewGzipWriterLevel(w io.Writer, level int, opts ...Option) (*BufWriter, error) {
+	if _, err := levelMode(level); err != nil {
+		return nil, err
+	}
+	return NewGzipWriter(w, append(opts[:len(opts):len(opts)], Level(level))...), nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestLevelMode(t *testing.T) {
+	cases := []struct {
+		level int
+		mode  deflateMode
+		err   error
+	}{
+		{NoCompression, modeStored, nil},
+		{BestSpeed, modeFixed, nil},
+		{2, modeDynamic, nil},
+		{BestCompression, modeDynamic, nil},
+		{DefaultCompression, modeDynamic, nil},
+		{HuffmanOnlyLevel, modeHuffmanOnly, nil},
+		{10, modeDynamic, errors.InvalidCompressionLevel},
+		{-3, modeDynamic, errors.InvalidCompressionLevel},
+	}
+	for _, c := range cases {
+		mode, err := levelMode(c.level)
+		if err != c.err {
+			t.Fatalf("level %d: expected error %v, got %v", c.level, c.err, err)
+		}
+		if err == nil && mode != c.mode {
+			t.Fatalf("level %d: expected mode %d, got %d", c.level, c.mode, mode)
+		}
+	}
+	if _, err := NewGzipWriterLevel(io.Discard, 10); err != errors.InvalidCompressionLevel {
+		t.Fatalf("expected invalid compression level error, got %v", err)
+	}
+	if opt := newOption([]Option{Level(10), Level(BestSpeed)}); opt.err != errors.InvalidCompressionLevel {
+		t.Fatalf("expected invalid compression level error, got %v", opt.err)
+	}
+	if opt := newOption([]Option{BlockSize(1024), Level(BestSpeed)}); opt.err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument error, got %v", opt.err)
+	}
+}
+
+func TestGzipWriterLevel(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	for level := HuffmanOnlyLevel; level <= BestCompression; level++ {
+		output := bytes.NewBuffer(nil)
+		w, err := NewGzipWriterLevel(output, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Write(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+
+		xfl := output.Bytes()[8]
+		switch {
+		case level == BestSpeed && xfl != 4,
+			level == BestCompression && xfl != 2:
+			t.Fatalf("level %d: unexpected XFL %d", level, xfl)
+		}
+
+		r, err := gzip.NewReader(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if !bytes.Equal(data, input) {
+			t.Fatalf("level %d: decompressed data is not consistent with input", level)
+		}
+	}
+}
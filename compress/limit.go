@@ -0,0 +1,123 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"github.com/intel/ixl-go/errors"
+)
+
+// limits protects the decompression from the decompression bombs, zero means unlimited.
+type limits struct {
+	maxOutput int64 // max total output of a stream
+	maxRatio  int64 // max ratio of the output size to the input size
+	maxCall   int   // max output of one call
+}
+
+// Names of the limits reported by errors.LimitError.
+const (
+	limitOutput = "max output size"
+	limitRatio  = "max expansion ratio"
+	limitCall   = "max output size per call"
+)
+
+// MaxOutputSize limits the total size of the data decompressed from one stream,
+// the decompression fails with an *errors.LimitError once the size exceeds the limit.
+//
+// For GzipReader, the limit applies to the total size of all members.
+// For DecompressAll, the limit applies to each call.
+func MaxOutputSize(size int64) Option {
+	return func(opt *option) {
+		if size <= 0 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.limits.maxOutput = size
+	}
+}
+
+// MaxRatio limits the ratio of the decompressed size to the compressed size,
+// the decompression fails with an *errors.LimitError once the ratio exceeds the limit.
+//
+// The max ratio of the deflate format is about 1032.
+func MaxRatio(ratio int) Option {
+	return func(opt *option) {
+		if ratio <= 0 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.limits.maxRatio = int64(ratio)
+	}
+}
+
+// MaxCallOutput limits the size of the data decompressed by one call:
+// Read never returns more than `size` bytes,
+// and DecompressAll fails with an *errors.LimitError if the decompressed data is larger than `size`.
+func MaxCallOutput(size int) Option {
+	return func(opt *option) {
+		if size <= 0 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.limits.maxCall = size
+	}
+}
+
+// resetLimits clears the sizes counted for the limits.
+func (i *Inflate) resetLimits() {
+	i.inputTotal = 0
+	i.outputTotal = 0
+	i.limitErr = nil
+}
+
+// limitRead shrinks the buffer of Read, so the output exceeding the limits can be detected without decompressing more.
+func (i *Inflate) limitRead(data []byte) []byte {
+	if i.limits.maxCall > 0 && len(data) > i.limits.maxCall {
+		data = data[:i.limits.maxCall]
+	}
+	if i.limits.maxOutput > 0 {
+		// one more byte to detect the exceeding
+		if remain := i.limits.maxOutput - i.outputTotal + 1; int64(len(data)) > remain {
+			data = data[:remain]
+		}
+	}
+	return data
+}
+
+// checkRead counts the `n` bytes returned by Read, and checks the limits.
+// The bytes exceeding the max output size are dropped.
+func (i *Inflate) checkRead(n int) (int, error) {
+	i.outputTotal += int64(n)
+	if i.limits.maxOutput > 0 && i.outputTotal > i.limits.maxOutput {
+		n -= int(i.outputTotal - i.limits.maxOutput)
+		i.outputTotal = i.limits.maxOutput
+		i.limitErr = &errors.LimitError{Limit: limitOutput, Value: i.limits.maxOutput}
+		return n, i.limitErr
+	}
+	// the input fetched but not consumed is counted, the hardware may hold some bits of it.
+	input := i.inputTotal + int64(i.remnant)
+	if i.limits.maxRatio > 0 && i.outputTotal > i.limits.maxRatio*input {
+		i.limitErr = &errors.LimitError{Limit: limitRatio, Value: i.limits.maxRatio}
+		return n, i.limitErr
+	}
+	return n, nil
+}
+
+// limitDecompressAll shrinks the output buffer of DecompressAll to the limits,
+// it returns the error reported if the output overflows the shrunk buffer.
+func (i *Inflate) limitDecompressAll(compressed, raw []byte) ([]byte, error) {
+	var err error
+	if i.limits.maxCall > 0 && len(raw) > i.limits.maxCall {
+		raw = raw[:i.limits.maxCall]
+		err = &errors.LimitError{Limit: limitCall, Value: int64(i.limits.maxCall)}
+	}
+	if i.limits.maxOutput > 0 && int64(len(raw)) > i.limits.maxOutput {
+		raw = raw[:i.limits.maxOutput]
+		err = &errors.LimitError{Limit: limitOutput, Value: i.limits.maxOutput}
+	}
+	if max := i.limits.maxRatio * int64(len(compressed)); i.limits.maxRatio > 0 && int64(len(raw)) > max {
+		raw = raw[:max]
+		err = &errors.LimitError{Limit: limitRatio, Value: i.limits.maxRatio}
+	}
+	return raw, err
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+func TestLimitOptions(t *testing.T) {
+	opt := newOption([]Option{MaxOutputSize(100), MaxRatio(10), MaxCallOutput(20)})
+	if opt.err != nil || opt.limits != (limits{maxOutput: 100, maxRatio: 10, maxCall: 20}) {
+		t.Fatalf("unexpected limits %+v", opt.limits)
+	}
+	for _, o := range []Option{MaxOutputSize(0), MaxRatio(-1), MaxCallOutput(0)} {
+		if newOption([]Option{o}).err != errors.InvalidArgument {
+			t.Fatal("expected invalid argument")
+		}
+	}
+}
+
+func TestInflate_Limits(t *testing.T) {
+	i := &Inflate{limits: limits{maxOutput: 100, maxCall: 40}}
+	if data := i.limitRead(make([]byte, 1000)); len(data) != 40 {
+		t.Fatalf("expected 40 bytes buffer, got %d", len(data))
+	}
+	i.outputTotal = 90
+	if data := i.limitRead(make([]byte, 1000)); len(data) != 11 {
+		t.Fatalf("expected 11 bytes buffer, got %d", len(data))
+	}
+	n, err := i.checkRead(11)
+	if n != 10 {
+		t.Fatalf("expected 10 bytes returned, got %d", n)
+	}
+	limitErr, ok := err.(*errors.LimitError)
+	if !ok || limitErr.Limit != limitOutput || limitErr.Value != 100 {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	i = &Inflate{limits: limits{maxRatio: 10}}
+	i.inputTotal = 5
+	i.remnant = 5
+	if _, err = i.checkRead(100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.checkRead(1); err == nil {
+		t.Fatal("expected ratio error")
+	}
+
+	raw, err := i.limitDecompressAll(make([]byte, 10), make([]byte, 1000))
+	if len(raw) != 100 || err == nil {
+		t.Fatalf("expected output buffer limited by ratio, got %d", len(raw))
+	}
+	i.resetLimits()
+	if i.inputTotal != 0 || i.outputTotal != 0 || i.limitErr != nil {
+		t.Fatal("sizes are not reset")
+	}
+}
+
+func TestGzipReader_MaxOutputSize(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := make([]byte, 1024*1024)
+	g := NewGzip(nil)
+	member, err := g.CompressAll(nil, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := append(append([]byte{}, member...), member...)
+	r, err := NewGzipReader(bytes.NewReader(stream), MaxOutputSize(int64(len(input)+100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, r)
+	if _, ok := err.(*errors.LimitError); !ok {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if n != int64(len(input)+100) {
+		t.Fatalf("expected %d bytes decompressed, got %d", len(input)+100, n)
+	}
+
+	r, err = NewGzipReader(bytes.NewReader(member), MaxRatio(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.Copy(io.Discard, r)
+	if _, ok := err.(*errors.LimitError); !ok {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+
+	i, err := NewInflate(nil, MaxCallOutput(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _ := NewDeflate(nil)
+	compressed, _ := d.CompressAll(nil, input[:4096])
+	_, err = i.DecompressAll(compressed, make([]byte, 4096))
+	if _, ok := err.(*errors.LimitError); !ok {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+}
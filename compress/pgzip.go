@@ -0,0 +1,305 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"runtime"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// defaultChunkSize is the default chunk size of ParallelGzip.
+const defaultChunkSize = 1024 * 1024
+
+// finalStoredBlock is an empty final stored block, used to terminate a byte aligned deflate stream.
+var finalStoredBlock = [5]byte{0b001, 0x00, 0x00, 0xff, 0xff}
+
+// Concurrency sets the max number of chunks compressed concurrently by ParallelGzip.
+// The default value is GOMAXPROCS.
+func Concurrency(n int) Option {
+	return func(opt *option) {
+		if n < 1 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.concurrency = n
+	}
+}
+
+// ChunkSize sets the size of the chunk compressed as a unit by ParallelGzip.
+// The default value is 1MB.
+func ChunkSize(size int) Option {
+	return func(opt *option) {
+		if size < 1 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.chunkSize = size
+	}
+}
+
+// ParallelGzip is an object to compress data using gzip format with multiple goroutines,
+// which makes one stream able to use many work queues or devices.
+//
+// The written data is split into chunks, and the chunks are compressed concurrently.
+// Every chunk is compressed into non-final deflate blocks followed by an empty stored block,
+// so the compressed chunks are byte aligned and can be written in order as one deflate stream.
+// The CRC-32 of the chunks are combined into the gzip trailer.
+//
+// Notice:
+//
+//  1. At most `Concurrency` chunks are compressed at the same time,
+//     the memory used is about (Concurrency + 1) * ChunkSize * 2.
+//  2. Close does not close the underlying writer.
+type ParallelGzip struct {
+	Header
+	UTF8 bool // can be used with gzip command
+
+	w           io.Writer
+	opts        []Option
+	level       int
+	chunkSize   int
+	concurrency int
+
+	wroteHeader bool
+	closed      bool
+	err         error
+	buf         []byte
+	sum         int64
+	crc         uint32
+
+	verifyFailures int64
+
+	current *pgzipJob   // the chunk being filled
+	pending []*pgzipJob // the chunks being compressed, in order
+	free    []*pgzipJob
+}
+
+type pgzipJob struct {
+	input  []byte
+	size   int
+	output bytes.Buffer
+	d      *Deflate
+	crc    uint32
+	err    error
+	done   chan struct{}
+}
+
+func (j *pgzipJob) run() {
+	j.output.Reset()
+	j.d.Reset(&j.output)
+	j.err = j.d.writeChunk(j.input[:j.size])
+	j.crc = j.d.crc
+	j.done <- struct{}{}
+}
+
+// NewParallelGzip creates a new ParallelGzip writing compressed data to underlying writer `w`.
+func NewParallelGzip(w io.Writer, opts ...Option) (*ParallelGzip, error) {
+	opt := newOption(opts)
+	if opt.err != nil {
+		return nil, opt.err
+	}
+	if iaa.LoadContext() == nil {
+		return nil, errors.NoHardwareDeviceDetected
+	}
+	p := &ParallelGzip{
+		Header:      Header{OS: 255},
+		w:           w,
+		opts:        opts,
+		level:       opt.level,
+		chunkSize:   opt.chunkSize,
+		concurrency: opt.concurrency,
+	}
+	if p.chunkSize == 0 {
+		p.chunkSize = defaultChunkSize
+	}
+	if p.concurrency == 0 {
+		p.concurrency = runtime.GOMAXPROCS(0)
+	}
+	var err error
+	p.current, err = p.newJob()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *ParallelGzip) newJob() (*pgzipJob, error) {
+	if len(p.free) != 0 {
+		job := p.free[len(p.free)-1]
+		p.free = p.free[:len(p.free)-1]
+		return job, nil
+	}
+	d, err := NewDeflate(nil, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &pgzipJob{
+		input: mem.Alloc64ByteAligned(uintptr(p.chunkSize)),
+		d:     d,
+		done:  make(chan struct{}, 1),
+	}, nil
+}
+
+// Write compresses the data and writes compressed data into underlying writer `w`.
+// The data may be buffered until the chunk is full or Flush/Close is called.
+func (p *ParallelGzip) Write(data []byte) (n int, err error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	if p.closed {
+		return 0, errors.ErrWriterClosed
+	}
+	for len(data) > 0 {
+		if p.current == nil {
+			p.current, err = p.newJob()
+			if err != nil {
+				return n, err
+			}
+		}
+		size := copy(p.current.input[p.current.size:], data)
+		p.current.size += size
+		n += size
+		data = data[size:]
+		if p.current.size == len(p.current.input) {
+			err = p.dispatch()
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// dispatch starts compressing the current chunk,
+// the oldest compressed chunk is written out first if there are too many pending chunks.
+func (p *ParallelGzip) dispatch() error {
+	if !p.wroteHeader {
+		p.wroteHeader = true
+		p.buf, p.err = appendGzipHeader(p.buf[:0], &p.Header, p.UTF8, p.level)
+		if p.err != nil {
+			return p.err
+		}
+		_, p.err = p.w.Write(p.buf)
+		if p.err != nil {
+			return p.err
+		}
+	}
+	if p.current == nil || p.current.size == 0 {
+		return nil
+	}
+	if len(p.pending) >= p.concurrency {
+		if err := p.writeOldest(); err != nil {
+			return err
+		}
+	}
+	job := p.current
+	p.current = nil
+	p.pending = append(p.pending, job)
+	go job.run()
+	return nil
+}
+
+// writeOldest waits for the oldest pending chunk and writes it into the underlying writer.
+func (p *ParallelGzip) writeOldest() error {
+	job := p.pending[0]
+	<-job.done
+	copy(p.pending, p.pending[1:])
+	p.pending = p.pending[:len(p.pending)-1]
+	size := job.size
+	job.size = 0
+	p.free = append(p.free, job)
+
+	p.verifyFailures += job.d.verifyFailures
+	job.d.verifyFailures = 0
+	if p.err != nil {
+		return p.err
+	}
+	if job.err != nil {
+		p.err = job.err
+		return p.err
+	}
+	_, p.err = p.w.Write(job.output.Bytes())
+	if p.err != nil {
+		return p.err
+	}
+	p.crc = crc32Combine(p.crc, job.crc, int64(size))
+	p.sum += int64(size)
+	return nil
+}
+
+// writeAll waits for all pending chunks and writes them into the underlying writer.
+func (p *ParallelGzip) writeAll() error {
+	for len(p.pending) != 0 {
+		// keep waiting even if error happened, the pending chunks are still using the buffers.
+		_ = p.writeOldest()
+	}
+	return p.err
+}
+
+// Flush compresses all buffered data and writes them into the underlying writer.
+// The output is aligned to byte boundary after flushing.
+func (p *ParallelGzip) Flush() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.closed {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *ParallelGzip) flush() error {
+	if err := p.dispatch(); err != nil {
+		return err
+	}
+	return p.writeAll()
+}
+
+// Close flushes all buffered data and writes the gzip trailer.
+// It does not close the underlying writer.
+func (p *ParallelGzip) Close() error {
+	if p.closed {
+		return p.err
+	}
+	p.closed = true
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.flush(); err != nil {
+		return err
+	}
+	p.buf = append(p.buf[:0], finalStoredBlock[:]...)
+	p.buf = binary.LittleEndian.AppendUint32(p.buf, p.crc)
+	p.buf = binary.LittleEndian.AppendUint32(p.buf, uint32(p.sum))
+	_, p.err = p.w.Write(p.buf)
+	return p.err
+}
+
+// Reset discards the state and makes the ParallelGzip writing into `w`,
+// the options and the allocated buffers are kept.
+func (p *ParallelGzip) Reset(w io.Writer) {
+	for _, job := range p.pending {
+		<-job.done
+		job.size = 0
+		p.free = append(p.free, job)
+	}
+	p.pending = p.pending[:0]
+	if p.current != nil {
+		p.current.size = 0
+	}
+	p.w = w
+	p.Header = Header{OS: 255}
+	p.wroteHeader = false
+	p.closed = false
+	p.err = nil
+	p.sum = 0
+	p.crc = 0
+	p.verifyFailures = 0
+}
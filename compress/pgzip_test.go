@@ -0,0 +1,91 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestParallelGzipOptions(t *testing.T) {
+	_, err := NewParallelGzip(io.Discard, Concurrency(0))
+	if err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+	_, err = NewParallelGzip(io.Discard, ChunkSize(-1))
+	if err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument error, got %v", err)
+	}
+}
+
+func TestParallelGzip(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	p, err := NewParallelGzip(io.Discard, Concurrency(4), ChunkSize(100*1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, 1000, 100 * 1024, 1024*1024 + 3, 4 * 1024 * 1024} {
+		input := []byte(testutil.RandomText(size))
+		output := bytes.NewBuffer(nil)
+		p.Reset(output)
+		p.Name = "hallo.txt"
+		// write in small pieces to cover the buffering
+		for i := 0; i < len(input); i += 7000 {
+			end := i + 7000
+			if end > len(input) {
+				end = len(input)
+			}
+			_, err = p.Write(input[i:end])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i%(70000*3) == 0 {
+				err = p.Flush()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		err = p.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := gzip.NewReader(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Name != "hallo.txt" {
+			t.Fatalf("unexpected name %q", r.Name)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal("error happened while gunzip", size, err)
+		}
+		if !bytes.Equal(data, input) {
+			t.Fatal("decompressed data is not consistent with input")
+		}
+	}
+}
+
+func BenchmarkParallelGzip(b *testing.B) {
+	if !Ready() {
+		b.Skip("IAA devices not found")
+	}
+	text := []byte(testutil.RandomText(16 * 1024 * 1024))
+	p, _ := NewParallelGzip(io.Discard)
+	b.SetBytes(int64(len(text)))
+	for j := 0; j < b.N; j++ {
+		p.Reset(io.Discard)
+		_, _ = p.Write(text)
+		p.Close()
+	}
+}
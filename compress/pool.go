@@ -0,0 +1,207 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"io"
+	"sync"
+)
+
+// Pool caches the compressors and decompressors created with the same options,
+// so they are reused without allocating the aligned buffers and the hardware states again.
+//
+// The objects got from a Pool must be put back into the same Pool,
+// and must not be used after they are put back.
+// A Pool is safe for concurrent use by multiple goroutines.
+type Pool struct {
+	opts        []Option
+	deflates    sync.Pool
+	gzips       sync.Pool
+	zlibs       sync.Pool
+	inflates    sync.Pool
+	gzipReaders sync.Pool
+	zlibReaders sync.Pool
+}
+
+// NewPool creates a new Pool, the objects are created with the options.
+func NewPool(opts ...Option) *Pool {
+	return &Pool{opts: opts}
+}
+
+// defaultPool is used by the package level Get and Put functions.
+var defaultPool = NewPool()
+
+// GetDeflate returns a Deflate writing to `w`.
+func (p *Pool) GetDeflate(w io.Writer) (*Deflate, error) {
+	if d, ok := p.deflates.Get().(*Deflate); ok {
+		d.Reset(w)
+		return d, nil
+	}
+	return NewDeflate(w, p.opts...)
+}
+
+// PutDeflate puts the Deflate back into the pool.
+func (p *Pool) PutDeflate(d *Deflate) {
+	if d == nil {
+		return
+	}
+	d.Reset(nil)
+	p.deflates.Put(d)
+}
+
+// GetGzipWriter returns a Gzip writing to `w`, the Header is reset.
+func (p *Pool) GetGzipWriter(w io.Writer) *Gzip {
+	if g, ok := p.gzips.Get().(*Gzip); ok {
+		g.Reset(w)
+		return g
+	}
+	return NewGzip(w, p.opts...)
+}
+
+// PutGzipWriter puts the Gzip back into the pool.
+func (p *Pool) PutGzipWriter(g *Gzip) {
+	if g == nil {
+		return
+	}
+	g.Reset(nil)
+	p.gzips.Put(g)
+}
+
+// GetZlibWriter returns a Zlib writing to `w`.
+func (p *Pool) GetZlibWriter(w io.Writer) *Zlib {
+	if z, ok := p.zlibs.Get().(*Zlib); ok {
+		z.Reset(w)
+		return z
+	}
+	return NewZlib(w, p.opts...)
+}
+
+// PutZlibWriter puts the Zlib back into the pool.
+func (p *Pool) PutZlibWriter(z *Zlib) {
+	if z == nil {
+		return
+	}
+	z.Reset(nil)
+	p.zlibs.Put(z)
+}
+
+// GetInflate returns an Inflate reading from `r`.
+func (p *Pool) GetInflate(r io.Reader) (*Inflate, error) {
+	if i, ok := p.inflates.Get().(*Inflate); ok {
+		i.Reset(r)
+		return i, nil
+	}
+	return NewInflate(r, p.opts...)
+}
+
+// PutInflate puts the Inflate back into the pool.
+func (p *Pool) PutInflate(i *Inflate) {
+	if i == nil {
+		return
+	}
+	i.Reset(nil)
+	p.inflates.Put(i)
+}
+
+// GetGzipReader returns a GzipReader reading from `r`, the gzip header is read immediately.
+func (p *Pool) GetGzipReader(r io.Reader) (*GzipReader, error) {
+	if z, ok := p.gzipReaders.Get().(*GzipReader); ok {
+		if err := z.Reset(r); err != nil {
+			p.PutGzipReader(z)
+			return nil, err
+		}
+		return z, nil
+	}
+	return NewGzipReader(r, p.opts...)
+}
+
+// PutGzipReader puts the GzipReader back into the pool.
+func (p *Pool) PutGzipReader(z *GzipReader) {
+	if z == nil {
+		return
+	}
+	z.release()
+	p.gzipReaders.Put(z)
+}
+
+// GetZlibReader returns a ZlibReader reading from `r`, the zlib header is read immediately.
+func (p *Pool) GetZlibReader(r io.Reader) (*ZlibReader, error) {
+	if z, ok := p.zlibReaders.Get().(*ZlibReader); ok {
+		if err := z.Reset(r, nil); err != nil {
+			p.PutZlibReader(z)
+			return nil, err
+		}
+		return z, nil
+	}
+	return NewZlibReader(r, p.opts...)
+}
+
+// PutZlibReader puts the ZlibReader back into the pool.
+func (p *Pool) PutZlibReader(z *ZlibReader) {
+	if z == nil {
+		return
+	}
+	z.release()
+	p.zlibReaders.Put(z)
+}
+
+// GetDeflate returns a Deflate writing to `w` from the default pool.
+func GetDeflate(w io.Writer) (*Deflate, error) {
+	return defaultPool.GetDeflate(w)
+}
+
+// PutDeflate puts the Deflate back into the default pool.
+func PutDeflate(d *Deflate) {
+	defaultPool.PutDeflate(d)
+}
+
+// GetGzipWriter returns a Gzip writing to `w` from the default pool.
+func GetGzipWriter(w io.Writer) *Gzip {
+	return defaultPool.GetGzipWriter(w)
+}
+
+// PutGzipWriter puts the Gzip back into the default pool.
+func PutGzipWriter(g *Gzip) {
+	defaultPool.PutGzipWriter(g)
+}
+
+// GetZlibWriter returns a Zlib writing to `w` from the default pool.
+func GetZlibWriter(w io.Writer) *Zlib {
+	return defaultPool.GetZlibWriter(w)
+}
+
+// PutZlibWriter puts the Zlib back into the default pool.
+func PutZlibWriter(z *Zlib) {
+	defaultPool.PutZlibWriter(z)
+}
+
+// GetInflate returns an Inflate reading from `r` from the default pool.
+func GetInflate(r io.Reader) (*Inflate, error) {
+	return defaultPool.GetInflate(r)
+}
+
+// PutInflate puts the Inflate back into the default pool.
+func PutInflate(i *Inflate) {
+	defaultPool.PutInflate(i)
+}
+
+// GetGzipReader returns a GzipReader reading from `r` from the default pool.
+func GetGzipReader(r io.Reader) (*GzipReader, error) {
+	return defaultPool.GetGzipReader(r)
+}
+
+// PutGzipReader puts the GzipReader back into the default pool.
+func PutGzipReader(z *GzipReader) {
+	defaultPool.PutGzipReader(z)
+}
+
+// GetZlibReader returns a ZlibReader reading from `r` from the default pool.
+func GetZlibReader(r io.Reader) (*ZlibReader, error) {
+	return defaultPool.GetZlibReader(r)
+}
+
+// PutZlibReader puts the ZlibReader back into the default pool.
+func PutZlibReader(z *ZlibReader) {
+	defaultPool.PutZlibReader(z)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestPool_GzipWriter(t *testing.T) {
+	p := NewPool(FixedMode())
+	g := p.GetGzipWriter(io.Discard)
+	if g.w != io.Discard || g.level != newOption([]Option{FixedMode()}).level {
+		t.Fatal("unexpected gzip writer")
+	}
+	g.Name = "hallo.txt"
+	p.PutGzipWriter(g)
+	if g.w != nil || g.Name != "" || g.OS != 255 {
+		t.Fatal("the gzip writer is not reset")
+	}
+	p.PutGzipWriter(nil)
+}
+
+func TestPool(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 8)
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				buf := bytes.NewBuffer(nil)
+				g := GetGzipWriter(buf)
+				_, err := g.ReadFrom(bytes.NewReader(input))
+				PutGzipWriter(g)
+				if err != nil {
+					errs <- err
+					return
+				}
+				compressed := buf.Bytes()
+				// verified by the standard library
+				sr, err := gzip.NewReader(bytes.NewReader(compressed))
+				if err != nil {
+					errs <- err
+					return
+				}
+				output, err := io.ReadAll(sr)
+				if err != nil || !bytes.Equal(output, input) {
+					errs <- io.ErrUnexpectedEOF
+					return
+				}
+
+				r, err := GetGzipReader(bytes.NewReader(compressed))
+				if err != nil {
+					errs <- err
+					return
+				}
+				output, err = io.ReadAll(r)
+				PutGzipReader(r)
+				if err != nil || !bytes.Equal(output, input) {
+					errs <- io.ErrUnexpectedEOF
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"io"
+
+	"github.com/intel/ixl-go/errors"
+)
+
+// bufferedReader is the underlying reader of GzipReader and ZlibReader,
+// the trailer and the next member are read exactly after the deflate stream.
+type bufferedReader interface {
+	io.Reader
+	io.ByteReader
+	peeker
+}
+
+// setReader sets the underlying reader, `r` is wrapped by a reused *bufio.Reader if it's not buffered.
+func setReader(r io.Reader, br **bufio.Reader) bufferedReader {
+	if b, ok := r.(bufferedReader); ok {
+		return b
+	}
+	if *br == nil {
+		*br = bufio.NewReader(r)
+	} else {
+		(*br).Reset(r)
+	}
+	return *br
+}
+
+// GzipReader decompresses data in gzip format, it's like the gzip.Reader of the standard library.
+// The Header is the header of the current member.
+//
+// Concatenated gzip members are decompressed as one stream by default, see Multistream.
+//
+// Notice: the same as Inflate, the data must be compressed by IAA or any compressor whose window size is not larger than 4KB.
+type GzipReader struct {
+	Header
+	r           bufferedReader
+	br          *bufio.Reader
+	inflate     *Inflate
+	digest      uint32
+	size        uint32
+	multistream bool
+	err         error
+}
+
+// NewGzipReader creates a new GzipReader reading the given reader, the gzip header is read immediately.
+//
+// If `r` does not implement Peek, Discard and io.ByteReader like *bufio.Reader,
+// the GzipReader may read more data than necessary from `r`.
+func NewGzipReader(r io.Reader, opts ...Option) (*GzipReader, error) {
+	inflate, err := NewInflate(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	z := &GzipReader{inflate: inflate}
+	err = z.Reset(r)
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// Reset discards the state and makes the GzipReader read from `r`, the gzip header is read immediately.
+func (z *GzipReader) Reset(r io.Reader) error {
+	z.r = setReader(r, &z.br)
+	z.digest = 0
+	z.size = 0
+	z.multistream = true
+	z.inflate.resetLimits()
+	z.err = z.readHeader()
+	return z.err
+}
+
+// Multistream controls whether the concatenated gzip members are decompressed as one stream (the default).
+// If disabled, Read returns io.EOF at the end of the current member,
+// then the next member can be read by calling Reset with the same underlying reader.
+func (z *GzipReader) Multistream(ok bool) {
+	z.multistream = ok
+}
+
+func (z *GzipReader) readHeader() error {
+	_, err := readGzipHeader(z.r, &z.Header)
+	if err != nil {
+		return err
+	}
+	z.inflate.resetStream(z.r)
+	return nil
+}
+
+// Read reads the decompressed data, the checksum and the size are verified at the end of each member.
+func (z *GzipReader) Read(p []byte) (n int, err error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n, z.err = z.inflate.Read(p)
+	z.digest = crc32.Update(z.digest, crc32.IEEETable, p[:n])
+	z.size += uint32(n)
+	if z.err != io.EOF {
+		return n, z.err
+	}
+
+	var trailer [8]byte
+	if _, err = io.ReadFull(z.r, trailer[:]); err != nil {
+		z.err = noEOF(err)
+		return n, z.err
+	}
+	if binary.LittleEndian.Uint32(trailer[:4]) != z.digest || binary.LittleEndian.Uint32(trailer[4:]) != z.size {
+		z.err = errors.ErrChecksum
+		return n, z.err
+	}
+	z.digest, z.size = 0, 0
+
+	if !z.multistream {
+		return n, io.EOF
+	}
+	if z.err = z.readHeader(); z.err != nil {
+		return n, z.err
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return z.Read(p)
+}
+
+// Close does not close the underlying reader.
+func (z *GzipReader) Close() error {
+	return nil
+}
+
+// release drops the references to the underlying reader.
+func (z *GzipReader) release() {
+	z.r = nil
+	if z.br != nil {
+		z.br.Reset(nil)
+	}
+	z.inflate.Reset(nil)
+	z.Header = Header{}
+	z.err = nil
+}
+
+// ZlibReader decompresses data in zlib format, it's like the reader of compress/zlib.
+//
+// Notice: the same as Inflate, the data must be compressed by IAA or any compressor whose window size is not larger than 4KB.
+type ZlibReader struct {
+	r       bufferedReader
+	br      *bufio.Reader
+	inflate *Inflate
+	dict    []byte
+	digest  hash.Hash32
+	err     error
+}
+
+// NewZlibReader creates a new ZlibReader reading the given reader, the zlib header is read immediately.
+//
+// If `r` does not implement Peek, Discard and io.ByteReader like *bufio.Reader,
+// the ZlibReader may read more data than necessary from `r`.
+func NewZlibReader(r io.Reader, opts ...Option) (*ZlibReader, error) {
+	return NewZlibReaderDict(r, nil, opts...)
+}
+
+// NewZlibReaderDict is like NewZlibReader but uses a preset dictionary,
+// it's ignored if the header doesn't require a dictionary.
+func NewZlibReaderDict(r io.Reader, dict []byte, opts ...Option) (*ZlibReader, error) {
+	inflate, err := NewInflate(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	z := &ZlibReader{inflate: inflate, digest: adler32.New()}
+	err = z.Reset(r, dict)
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// Reset discards the state and makes the ZlibReader read from `r` with the preset dictionary,
+// the zlib header is read immediately.
+func (z *ZlibReader) Reset(r io.Reader, dict []byte) error {
+	z.r = setReader(r, &z.br)
+	z.dict = dict
+	z.digest.Reset()
+	z.err = z.readHeader()
+	return z.err
+}
+
+// zlib format: https://www.rfc-editor.org/rfc/rfc1950#page-4
+func (z *ZlibReader) readHeader() error {
+	var hdr [6]byte
+	if _, err := io.ReadFull(z.r, hdr[:2]); err != nil {
+		return err
+	}
+	h := binary.BigEndian.Uint16(hdr[:2])
+	if hdr[0]&0x0f != zlibDeflate || hdr[0]>>4 > zlibMaxWindow || h%31 != 0 {
+		return errors.ErrZlibHeader
+	}
+	if hdr[1]&zlibFDICT == 0 {
+		z.inflate.Reset(z.r)
+		return nil
+	}
+	if _, err := io.ReadFull(z.r, hdr[2:]); err != nil {
+		return noEOF(err)
+	}
+	if z.dict == nil || binary.BigEndian.Uint32(hdr[2:]) != adler32.Checksum(z.dict) {
+		return errors.ErrDictionary
+	}
+	z.inflate.ResetDict(z.r, z.dict)
+	return nil
+}
+
+// Read reads the decompressed data, the checksum is verified at the end of the stream.
+func (z *ZlibReader) Read(p []byte) (n int, err error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n, z.err = z.inflate.Read(p)
+	_, _ = z.digest.Write(p[:n])
+	if z.err != io.EOF {
+		return n, z.err
+	}
+	var trailer [4]byte
+	if _, err = io.ReadFull(z.r, trailer[:]); err != nil {
+		z.err = noEOF(err)
+		return n, z.err
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != z.digest.Sum32() {
+		z.err = errors.ErrChecksum
+	}
+	return n, z.err
+}
+
+// Close does not close the underlying reader.
+func (z *ZlibReader) Close() error {
+	return nil
+}
+
+// release drops the references to the underlying reader and the dictionary.
+func (z *ZlibReader) release() {
+	z.r = nil
+	if z.br != nil {
+		z.br.Reset(nil)
+	}
+	z.inflate.Reset(nil)
+	z.dict = nil
+	z.err = nil
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestSetReader(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("hello"))
+	var buf *bufio.Reader
+	if setReader(br, &buf) != br || buf != nil {
+		t.Fatal("buffered reader should be used directly")
+	}
+	r := setReader(strings.NewReader("hello"), &buf)
+	if r != buf || buf == nil {
+		t.Fatal("unbuffered reader should be wrapped")
+	}
+	if setReader(strings.NewReader("world"), &buf) != r {
+		t.Fatal("the wrapper should be reused")
+	}
+}
+
+func TestInflate_Remainder(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	d, err := NewDeflate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := d.CompressAll(nil, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trailing := []byte("trailing data")
+	stream := append(append([]byte{}, compressed...), trailing...)
+
+	// the read ahead bytes are kept in Remainder
+	i, err := NewInflate(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+	if i.Consumed() != int64(len(compressed)) {
+		t.Fatalf("expected %d bytes consumed, got %d", len(compressed), i.Consumed())
+	}
+	if !bytes.Equal(i.Remainder(), trailing) {
+		t.Fatalf("unexpected remainder %q", i.Remainder())
+	}
+
+	// the bytes after the stream are left in the buffered reader
+	br := bufio.NewReader(bytes.NewReader(stream))
+	i.Reset(br)
+	output, err = io.ReadAll(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+	rest, _ := io.ReadAll(br)
+	if !bytes.Equal(rest, trailing) || len(i.Remainder()) != 0 {
+		t.Fatalf("unexpected data left in the reader %q", rest)
+	}
+
+	// the final block ends exactly at the end of the input buffer
+	i, err = NewInflateWithBufferSize(bytes.NewReader(stream), len(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err = io.ReadAll(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) || i.Consumed() != int64(len(compressed)) {
+		t.Fatal("the stream should end at the final block")
+	}
+	if !bytes.Equal(i.Remainder(), trailing) {
+		t.Fatalf("unexpected remainder %q", i.Remainder())
+	}
+}
+
+func TestByteReader(t *testing.T) {
+	b := &byteReader{}
+	b.reset(strings.NewReader("hello world"))
+	data, err := b.Peek(5)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected peek %q %v", data, err)
+	}
+	_, _ = b.Discard(3)
+	data, err = b.Peek(4)
+	if err != nil || string(data) != "lo w" {
+		t.Fatalf("unexpected peek %q %v", data, err)
+	}
+	_, _ = b.Discard(2)
+	data, err = b.Peek(100)
+	if err != io.EOF || string(data) != " world" {
+		t.Fatalf("unexpected peek %q %v", data, err)
+	}
+	if !Ready() {
+		return
+	}
+	i, err := NewInflate(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.peek != i.bytes || i.br != nil {
+		t.Fatal("an io.ByteReader should be read by ReadByte")
+	}
+	i.Reset(struct{ io.Reader }{strings.NewReader("hello")})
+	if i.peek != i.br || i.br == nil {
+		t.Fatal("a reader without ReadByte should be wrapped by bufio.Reader")
+	}
+}
+
+func TestInflate_Concatenated(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	d, err := NewDeflate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := d.CompressAll(nil, input[:30000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := d.CompressAll(nil, input[30000:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := append(append([]byte{}, first...), second...)
+	// the final EOB of the first stream is before, at and after the end of the input buffer
+	for _, size := range []int{len(first) - 1, len(first), len(first) + 1, len(stream), 4096} {
+		br := bufio.NewReader(bytes.NewReader(stream))
+		i, err := NewInflateWithBufferSize(br, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n, expected := range [][]byte{input[:30000], input[30000:]} {
+			output, err := io.ReadAll(i)
+			if err != nil {
+				t.Fatal(size, err)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Fatalf("buffer size %d: stream %d is not consistent with input", size, n)
+			}
+			if i.Consumed() != int64(len([][]byte{first, second}[n])) {
+				t.Fatalf("buffer size %d: unexpected consumed size %d of stream %d", size, i.Consumed(), n)
+			}
+			i.Reset(br)
+		}
+		if _, err = br.ReadByte(); err != io.EOF {
+			t.Fatalf("buffer size %d: expected the end of the input", size)
+		}
+	}
+}
+
+func TestGzipReader(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	g := NewGzip(nil)
+	g.Name = "first.txt"
+	member1, err := g.CompressAll(nil, input[:60000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Name = "second.txt"
+	member2, err := g.CompressAll(nil, input[60000:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := append(append([]byte{}, member1...), member2...)
+
+	r, err := NewGzipReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "first.txt" {
+		t.Fatalf("unexpected name %q", r.Name)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	// read members one by one
+	br := bufio.NewReader(bytes.NewReader(stream))
+	err = r.Reset(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Multistream(false)
+	output, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input[:60000]) {
+		t.Fatal("unexpected data of the first member")
+	}
+	err = r.Reset(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "second.txt" {
+		t.Fatalf("unexpected name %q", r.Name)
+	}
+
+	// corrupted trailer
+	stream[len(member1)-8]++
+	err = r.Reset(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(r)
+	if err != errors.ErrChecksum {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+}
+
+func TestZlibReader(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	buf := bytes.NewBuffer(nil)
+	z := NewZlib(buf)
+	_, err := z.ReadFrom(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewZlibReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+
+	// dictionary
+	dict := []byte("hello world")
+	buf.Reset()
+	w, _ := zlib.NewWriterLevelDict(buf, zlib.BestSpeed, dict)
+	_, _ = w.Write([]byte("hello world, hello world"))
+	w.Close()
+	_, err = NewZlibReader(bytes.NewReader(buf.Bytes()))
+	if err != errors.ErrDictionary {
+		t.Fatalf("expected dictionary error, got %v", err)
+	}
+	r, err = NewZlibReaderDict(bytes.NewReader(buf.Bytes()), dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "hello world, hello world" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
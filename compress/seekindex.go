@@ -0,0 +1,511 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+	"unsafe"
+
+	"github.com/intel/ixl-go/compress/internal/zran"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// seekInputSize is the size of the compressed data decompressed by one job while building the SeekIndex by IAA,
+// a checkpoint can only be recorded between jobs.
+const seekInputSize = 16 * 1024
+
+// seekOutputSize is the size of the output buffer while building the SeekIndex by IAA.
+const seekOutputSize = 256 * 1024
+
+// SeekIndex is an index of the checkpoints of a gzip or deflate stream, it's like the index built by zran.c of zlib.
+// The decompression can be resumed from any checkpoint, see SeekIndex.NewReader.
+// The gzip files may have multiple members (e.g. concatenated or compressed by pigz),
+// the beginning of every member is a checkpoint too.
+//
+// The index is built by Inflate if IAA is available, a checkpoint records the offset of the compressed data,
+// the offset of the uncompressed data, and the decompression state of the hardware (the AECS),
+// so the decompression is resumed by IAA from the state.
+// The history buffer of the hardware is 4KB, the streams compressed with a larger window
+// (e.g. gzip of the command line) fail to be decompressed by IAA, and the index is built in software instead:
+// a checkpoint records the bit offset of a deflate block and the 32KB history window before the block.
+type SeekIndex struct {
+	Span int64 // the min distance between the checkpoints in uncompressed data
+	Size int64 // the size of the uncompressed data
+
+	gzip     bool
+	hardware bool // the checkpoints are the decompression states of IAA
+	members  []seekMember
+	points   []seekPoint
+}
+
+// seekMember is the beginning of a gzip member, a raw deflate stream has one member.
+type seekMember struct {
+	in  int64 // offset of the deflate data
+	out int64 // offset of the uncompressed data
+}
+
+type seekPoint struct {
+	in     int64  // offset of the byte holding the first bit of the block, or the next byte of the input of IAA
+	bit    uint8  // index of the first bit of the block in the byte
+	out    int64  // offset of the uncompressed data
+	member int    // the member holding the block
+	window []byte // the history window before the block
+
+	aecs *iaa.DecompressAECS // the decompression state of IAA
+}
+
+// BuildSeekIndex decompresses the stream from the current position of `r` once,
+// and records a checkpoint every `span` bytes of uncompressed data.
+// The stream is gzip if it starts with the gzip magic number, otherwise it's a raw deflate stream.
+// The checksums of the gzip members are verified.
+//
+// The stream is decompressed by Inflate with the options if IAA is available.
+// If IAA fails to decompress it, `r` is rewound and the index is built in software.
+// An *errors.LimitError is returned without the fallback if any decompression limit is exceeded.
+func BuildSeekIndex(r io.ReadSeeker, span int64, opts ...Option) (*SeekIndex, error) {
+	if span <= 0 {
+		return nil, errors.InvalidArgument
+	}
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if Ready() {
+		x, err := buildSeekIndexInflate(r, start, span, opts)
+		if _, lim := err.(*errors.LimitError); err == nil || lim {
+			return x, err
+		}
+		if _, ok := err.(errors.Error); !ok {
+			return nil, err
+		}
+		if _, err = r.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return buildSeekIndexSoftware(r, start, span)
+}
+
+// isGzip reports whether the stream starts with the gzip magic number.
+func isGzip(br *bufio.Reader) bool {
+	magic, _ := br.Peek(2)
+	return len(magic) == 2 && magic[0] == fixedGzipHeader[0] && magic[1] == fixedGzipHeader[1]
+}
+
+// buildSeekIndexInflate builds the index by Inflate, a checkpoint is recorded after a job consuming all of its input.
+func buildSeekIndexInflate(r io.Reader, in int64, span int64, opts []Option) (*SeekIndex, error) {
+	i, err := NewInflateWithBufferSize(nil, seekInputSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(r, seekInputSize)
+	x := &SeekIndex{Span: span, gzip: isGzip(br), hardware: true}
+	output := mem.Alloc64ByteAligned(seekOutputSize)
+	for {
+		if x.gzip {
+			var hdr Header
+			n, err := readGzipHeader(br, &hdr)
+			if err != nil {
+				return nil, err
+			}
+			in += int64(n)
+		}
+		member := seekMember{in: in, out: x.Size}
+		x.members = append(x.members, member)
+		// *bufio.Reader supports peeking, so the Inflate stops at the end of the member
+		i.resetStream(br)
+		crc := uint32(0)
+		for {
+			if i.state == middle && i.remnant == 0 && len(i.outputRemnant) == 0 && !i.finished &&
+				x.Size-x.lastPoint() >= x.Span {
+				aecs := new(iaa.DecompressAECS)
+				*aecs = i.aecsPair[i.toggle]
+				x.points = append(x.points, seekPoint{
+					in:     in + i.Consumed(),
+					out:    x.Size,
+					member: len(x.members) - 1,
+					aecs:   aecs,
+				})
+			}
+			n, err := i.Read(output)
+			crc = crc32.Update(crc, crc32.IEEETable, output[:n])
+			x.Size += int64(n)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		in += i.Consumed()
+		if !x.gzip {
+			return x, nil
+		}
+		n, err := verifyGzipTrailer(br, crc, x.Size-member.out)
+		in += int64(n)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := br.Peek(1); err == io.EOF {
+			return x, nil
+		}
+	}
+}
+
+// buildSeekIndexSoftware builds the index by the software decoder, a checkpoint is recorded before a block.
+func buildSeekIndexSoftware(r io.Reader, in int64, span int64) (*SeekIndex, error) {
+	br := bufio.NewReader(r)
+	x := &SeekIndex{Span: span, gzip: isGzip(br)}
+	sum := &crcWriter{}
+	d := zran.NewDecoder(sum)
+	for {
+		if x.gzip {
+			var hdr Header
+			n, err := readGzipHeader(br, &hdr)
+			if err != nil {
+				return nil, err
+			}
+			in += int64(n)
+		}
+		member := seekMember{in: in, out: x.Size}
+		x.members = append(x.members, member)
+		sum.crc = 0
+		d.Reset(br)
+		for final := false; !final; {
+			if x.Size-x.lastPoint() >= x.Span {
+				offset, bit := d.Offset()
+				x.points = append(x.points, seekPoint{
+					in:     in + offset,
+					bit:    bit,
+					out:    x.Size,
+					member: len(x.members) - 1,
+					window: append([]byte(nil), d.Window()...),
+				})
+			}
+			var err error
+			final, err = d.ReadBlock()
+			if err != nil {
+				return nil, err
+			}
+			x.Size += sum.n
+			sum.n = 0
+		}
+		in += d.InputSize()
+		if !x.gzip {
+			return x, nil
+		}
+		n, err := verifyGzipTrailer(br, sum.crc, x.Size-member.out)
+		in += int64(n)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := br.Peek(1); err == io.EOF {
+			return x, nil
+		}
+	}
+}
+
+// verifyGzipTrailer reads the trailer of a gzip member, and checks the CRC-32 and the size of the member.
+func verifyGzipTrailer(r io.Reader, crc uint32, size int64) (int, error) {
+	var trailer [8]byte
+	n, err := io.ReadFull(r, trailer[:])
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	if binary.LittleEndian.Uint32(trailer[:4]) != crc || binary.LittleEndian.Uint32(trailer[4:]) != uint32(size) {
+		return n, errors.ErrChecksum
+	}
+	return n, nil
+}
+
+// crcWriter computes the CRC-32 and the size of the data written to it.
+type crcWriter struct {
+	crc uint32
+	n   int64
+}
+
+func (w *crcWriter) Write(p []byte) (int, error) {
+	w.crc = crc32.Update(w.crc, crc32.IEEETable, p)
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// lastPoint returns the uncompressed offset of the last checkpoint, including the beginning of the members.
+func (x *SeekIndex) lastPoint() int64 {
+	last := x.members[len(x.members)-1].out
+	if len(x.points) != 0 && x.points[len(x.points)-1].out > last {
+		last = x.points[len(x.points)-1].out
+	}
+	return last
+}
+
+// NewReader returns a reader decompressing the stream `r` indexed by the index from the uncompressed `offset`:
+// `r` is moved to the nearest checkpoint before the offset, and the data between them is skipped.
+// The reader stops at the end of the indexed stream, the trailers of the gzip members are not verified.
+//
+// If the index is built by IAA, the data is decompressed by Inflate resumed from the state of the checkpoint.
+// If IAA is not available, the member holding the offset is decompressed in software from its beginning.
+//
+// If the index is built in software, the data is decompressed by the software decoder
+// primed with the bits and the history window of the checkpoint,
+// compress/flate can't be used as it can't resume from a block not aligned to the byte boundary.
+func (x *SeekIndex) NewReader(r io.ReadSeeker, offset int64) (io.Reader, error) {
+	if offset < 0 || offset > x.Size || len(x.members) == 0 {
+		return nil, errors.InvalidArgument
+	}
+	s := &seekReader{x: x, r: r}
+	if x.hardware {
+		s.inflate, _ = NewInflate(nil)
+	}
+	if s.inflate == nil {
+		s.d = zran.NewDecoder(&s.buf)
+	}
+	s.member = sort.Search(len(x.members), func(n int) bool {
+		return x.members[n].out > offset
+	}) - 1
+	n := sort.Search(len(x.points), func(n int) bool {
+		return x.points[n].out > offset
+	}) - 1
+	var err error
+	out := x.members[s.member].out
+	if n >= 0 && x.points[n].out >= out && (s.inflate != nil || !x.hardware) {
+		p := &x.points[n]
+		s.member = p.member
+		out = p.out
+		err = s.open(p)
+	} else {
+		err = s.open(&seekPoint{in: x.members[s.member].in})
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.CopyN(io.Discard, s, offset-out)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// seekReader decompresses the indexed stream from a checkpoint, and continues with the next members.
+type seekReader struct {
+	x      *SeekIndex
+	r      io.ReadSeeker
+	member int
+
+	inflate *Inflate // resumes from the checkpoints recorded by IAA
+
+	d     *zran.Decoder // resumes from the checkpoints recorded in software
+	buf   bytes.Buffer  // the decompressed data of the current block
+	final bool          // the final block of the member is decompressed
+}
+
+// open starts to decompress the member from the checkpoint.
+// For the software decoder, the bits before the offset in the first byte are dropped.
+func (s *seekReader) open(p *seekPoint) error {
+	_, err := s.r.Seek(p.in, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReader(s.r)
+	if s.inflate != nil {
+		s.inflate.Reset(br)
+		if p.aecs != nil {
+			s.inflate.aecsPair[0] = *p.aecs
+			s.inflate.state = middle
+		}
+		return nil
+	}
+	s.d.Reset(br)
+	s.final = false
+	if p.bit == 0 {
+		s.d.Prime(0, 0, p.window)
+		return nil
+	}
+	b, err := br.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	s.d.Prime(b>>p.bit, uint(8-p.bit), p.window)
+	return nil
+}
+
+// next opens the next member, it returns io.EOF after the last member.
+func (s *seekReader) next() error {
+	if s.member+1 >= len(s.x.members) {
+		return io.EOF
+	}
+	s.member++
+	return s.open(&seekPoint{in: s.x.members[s.member].in})
+}
+
+func (s *seekReader) Read(p []byte) (n int, err error) {
+	if s.inflate != nil {
+		for {
+			n, err = s.inflate.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			if err = s.next(); err != nil {
+				return n, err
+			}
+			if n != 0 {
+				return n, nil
+			}
+		}
+	}
+	for s.buf.Len() == 0 {
+		if s.final {
+			if err = s.next(); err != nil {
+				return 0, err
+			}
+		}
+		s.buf.Reset()
+		if s.final, err = s.d.ReadBlock(); err != nil {
+			return 0, err
+		}
+	}
+	return s.buf.Read(p)
+}
+
+const seekIndexMagic = "IXLS"
+
+// flags of the binary form of SeekIndex.
+const (
+	seekFlagGzip     = 1
+	seekFlagHardware = 2
+)
+
+// aecsSize is the size of the decompression state saved in the checkpoints built by IAA.
+const aecsSize = int(unsafe.Sizeof(iaa.DecompressAECS{}))
+
+// MarshalBinary encodes the index into binary form.
+func (x *SeekIndex) MarshalBinary() ([]byte, error) {
+	pointSize := zran.WindowSize
+	if x.hardware {
+		pointSize = aecsSize
+	}
+	buf := make([]byte, 0, 64+len(x.members)*8+len(x.points)*(pointSize+16))
+	buf = append(buf, seekIndexMagic...)
+	flags := byte(0)
+	if x.gzip {
+		flags |= seekFlagGzip
+	}
+	if x.hardware {
+		flags |= seekFlagHardware
+	}
+	buf = append(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(x.Span))
+	buf = binary.AppendUvarint(buf, uint64(x.Size))
+	buf = binary.AppendUvarint(buf, uint64(len(x.members)))
+	for _, m := range x.members {
+		buf = binary.AppendUvarint(buf, uint64(m.in))
+		buf = binary.AppendUvarint(buf, uint64(m.out))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(x.points)))
+	for n := range x.points {
+		p := &x.points[n]
+		buf = binary.AppendUvarint(buf, uint64(p.in))
+		buf = append(buf, p.bit)
+		buf = binary.AppendUvarint(buf, uint64(p.out))
+		buf = binary.AppendUvarint(buf, uint64(p.member))
+		if x.hardware {
+			buf = append(buf, unsafe.Slice((*byte)(unsafe.Pointer(p.aecs)), aecsSize)...)
+			continue
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(p.window)))
+		buf = append(buf, p.window...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the index from binary form produced by MarshalBinary.
+func (x *SeekIndex) UnmarshalBinary(data []byte) error {
+	if len(data) < len(seekIndexMagic)+1 || string(data[:len(seekIndexMagic)]) != seekIndexMagic {
+		return errors.InvalidArgument
+	}
+	flags := data[len(seekIndexMagic)]
+	x.gzip = flags&seekFlagGzip != 0
+	x.hardware = flags&seekFlagHardware != 0
+	r := uvarintReader{data: data[len(seekIndexMagic)+1:]}
+	x.Span = int64(r.next())
+	x.Size = int64(r.next())
+	n := r.next()
+	if r.err != nil || n == 0 || n > uint64(len(r.data)) {
+		return errors.InvalidArgument
+	}
+	x.members = make([]seekMember, n)
+	for j := range x.members {
+		x.members[j].in = int64(r.next())
+		x.members[j].out = int64(r.next())
+	}
+	n = r.next()
+	if r.err != nil || n > uint64(len(r.data)) {
+		return errors.InvalidArgument
+	}
+	x.points = make([]seekPoint, n)
+	for j := range x.points {
+		p := &x.points[j]
+		p.in = int64(r.next())
+		p.bit = r.byte()
+		p.out = int64(r.next())
+		member := r.next()
+		if r.err != nil || p.bit > 7 || member >= uint64(len(x.members)) {
+			return errors.InvalidArgument
+		}
+		p.member = int(member)
+		if x.hardware {
+			if len(r.data) < aecsSize {
+				return errors.InvalidArgument
+			}
+			p.aecs = new(iaa.DecompressAECS)
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(p.aecs)), aecsSize), r.data)
+			r.data = r.data[aecsSize:]
+			continue
+		}
+		size := r.next()
+		if r.err != nil || size > zran.WindowSize || size > uint64(len(r.data)) {
+			return errors.InvalidArgument
+		}
+		p.window = append([]byte(nil), r.data[:size]...)
+		r.data = r.data[size:]
+	}
+	return r.err
+}
+
+type uvarintReader struct {
+	data []byte
+	err  error
+}
+
+func (r *uvarintReader) next() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.InvalidArgument
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *uvarintReader) byte() byte {
+	if len(r.data) == 0 {
+		r.err = errors.InvalidArgument
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestReadGzipHeader(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := gzip.NewWriter(buf)
+	w.Name = "hallo.txt"
+	w.Comment = "café"
+	w.Extra = []byte("extra")
+	w.ModTime = time.Unix(1700000000, 0)
+	_, _ = w.Write([]byte("hello"))
+	w.Close()
+	data := buf.Bytes()
+
+	var hdr Header
+	n, err := readGzipHeader(bufio.NewReader(bytes.NewReader(data)), &hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 10 + 2 + len("extra") + len("hallo.txt") + 1 + len("caf") + 1 + 1
+	if n != expected {
+		t.Fatalf("expected header size %d, got %d", expected, n)
+	}
+	if hdr.Name != w.Name || hdr.Comment != w.Comment || string(hdr.Extra) != "extra" ||
+		!hdr.ModTime.Equal(w.ModTime) || hdr.OS != w.OS {
+		t.Fatalf("unexpected header %+v", hdr)
+	}
+
+	// header with FHCRC
+	header := []byte{0x1f, 0x8b, 8, byte(gzipFileHCRC), 0, 0, 0, 0, 0, 255}
+	header = binary.LittleEndian.AppendUint16(header, uint16(crc32.ChecksumIEEE(header)))
+	_, err = readGzipHeader(bytes.NewReader(header), &hdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header[len(header)-1]++
+	_, err = readGzipHeader(bytes.NewReader(header), &hdr)
+	if err != errors.ErrGzipHeader {
+		t.Fatalf("expected header error, got %v", err)
+	}
+	_, err = readGzipHeader(bytes.NewReader(data[:5]), &hdr)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+}
+
+func TestSeekIndex_MarshalBinary(t *testing.T) {
+	x := &SeekIndex{Span: 1000, Size: 5000, gzip: true, members: []seekMember{{20, 0}, {900, 3000}}, points: make([]seekPoint, 2)}
+	x.points[0].in, x.points[0].bit, x.points[0].out = 300, 3, 1200
+	x.points[0].window = []byte("window")
+	x.points[1].in, x.points[1].out, x.points[1].member = 1000, 4200, 1
+	x.points[1].window = bytes.Repeat([]byte{0xab}, 32*1024)
+	data, err := x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SeekIndex{}
+	err = decoded.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(x, decoded) {
+		t.Fatal("decoded index is not consistent with the origin")
+	}
+	if decoded.UnmarshalBinary(data[:len(data)-1]) == nil {
+		t.Fatal("expected error for truncated data")
+	}
+
+	x = &SeekIndex{Span: 1000, Size: 5000, hardware: true, members: []seekMember{{0, 0}}, points: make([]seekPoint, 2)}
+	for j := range x.points {
+		x.points[j].in, x.points[j].out = int64(j+1)*300, int64(j+1)*1200
+		x.points[j].aecs = &iaa.DecompressAECS{}
+		x.points[j].aecs.CRC = uint32(j + 1)
+		x.points[j].aecs.InputAccumulatorData[j] = 0xab
+	}
+	data, err = x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded = &SeekIndex{}
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(x, decoded) {
+		t.Fatal("decoded index is not consistent with the origin")
+	}
+	if decoded.UnmarshalBinary(data[:len(data)-1]) == nil {
+		t.Fatal("expected error for truncated data")
+	}
+}
+
+func gzipMember(t *testing.T, data []byte, level int) []byte {
+	buf := bytes.NewBuffer(nil)
+	w, err := gzip.NewWriterLevel(buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func testSeekReader(t *testing.T, index *SeekIndex, compressed, input []byte, member int64) {
+	offsets := []int64{0, 1, 100 * 1024, 1000000, member - 50, member, int64(len(input)) - 100}
+	for j := 0; j < 20; j++ {
+		off, _ := rand.Int(rand.Reader, big.NewInt(int64(len(input)-100)))
+		offsets = append(offsets, off.Int64())
+	}
+	for _, offset := range offsets {
+		r, err := index.NewReader(bytes.NewReader(compressed), offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := make([]byte, 100)
+		_, err = io.ReadFull(r, p)
+		if err != nil {
+			t.Fatal(offset, err)
+		}
+		if !bytes.Equal(p, input[offset:offset+100]) {
+			t.Fatalf("unexpected data at offset %d", offset)
+		}
+	}
+	r, err := index.NewReader(bytes.NewReader(compressed), int64(len(input))-10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail, err := io.ReadAll(r); err != nil || !bytes.Equal(tail, input[len(input)-10:]) {
+		t.Fatal("unexpected data at the end of the stream", err)
+	}
+}
+
+func TestSeekIndex(t *testing.T) {
+	random := make([]byte, 300*1024)
+	_, _ = rand.Read(random)
+	// the words are repeated over the whole data, the matches may be far away than 4KB,
+	// and the huffman blocks end at arbitrary bit offsets.
+	words := make([]string, 2000)
+	for i := range words {
+		words[i] = testutil.RandomText(i%8+3) + " "
+	}
+	var input []byte
+	for len(input) < 2*1024*1024 {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+		input = append(input, words[n.Int64()]...)
+	}
+	input = append(input, random...)
+	first, second := input[:1500*1024], input[1500*1024:]
+	// the members compressed by compress/gzip use the 32KB window
+	gzipped := append(gzipMember(t, first, gzip.DefaultCompression), gzipMember(t, second, gzip.BestSpeed)...)
+	gzipped = append(gzipped, gzipMember(t, nil, gzip.DefaultCompression)...)
+	buf := bytes.NewBuffer(nil)
+	fw, _ := flate.NewWriter(buf, flate.BestCompression)
+	_, _ = fw.Write(input)
+	_ = fw.Close()
+	streams := [][]byte{gzipped, buf.Bytes()}
+	if Ready() {
+		g := NewGzip(nil)
+		iaaGzipped, err := g.CompressAll(nil, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		streams = append(streams, iaaGzipped)
+	}
+	for n, compressed := range streams {
+		index, err := BuildSeekIndex(bytes.NewReader(compressed), 10*1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 2 && !index.hardware {
+			t.Fatal("expected the index of the stream compressed by IAA to be built by IAA")
+		}
+		if index.Size != int64(len(input)) {
+			t.Fatalf("unexpected size %d", index.Size)
+		}
+		if len(index.points) < 5 {
+			t.Fatalf("too few checkpoints: %d", len(index.points))
+		}
+		shifted := 0
+		for _, p := range index.points {
+			if p.bit != 0 {
+				shifted++
+			}
+		}
+		if !index.hardware && shifted == 0 {
+			t.Fatal("expected checkpoints not aligned to byte boundary")
+		}
+		data, _ := index.MarshalBinary()
+		index = &SeekIndex{}
+		if err = index.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		testSeekReader(t, index, compressed, input, int64(len(first)))
+		if !Ready() {
+			// the members of an index built by IAA are decompressed from the beginning without IAA
+			index.hardware = true
+			testSeekReader(t, index, compressed, input, int64(len(first)))
+		}
+	}
+	if Ready() {
+		// the index is not built in software when a limit is exceeded
+		_, err := BuildSeekIndex(bytes.NewReader(streams[2]), 10*1024, MaxOutputSize(1024))
+		if _, ok := err.(*errors.LimitError); !ok {
+			t.Fatalf("expected limit error, got %v", err)
+		}
+	}
+	if _, err := BuildSeekIndex(bytes.NewReader(gzipped[:len(gzipped)-3]), 100*1024); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+	gzipped[len(gzipped)-5]++
+	if _, err := BuildSeekIndex(bytes.NewReader(gzipped), 100*1024); err != errors.ErrChecksum {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+}
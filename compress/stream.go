@@ -0,0 +1,107 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"io"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+var (
+	_ io.WriteCloser = &Deflate{}
+	_ io.WriteCloser = &Gzip{}
+	_ io.WriteCloser = &Zlib{}
+)
+
+// blockBuffer collects the data written by Write into blocks,
+// it makes Deflate, Gzip and Zlib work as io.WriteCloser.
+type blockBuffer struct {
+	buf       []byte // aligned buffer of the pending block
+	size      int    // size of the pending data
+	blockSize int    // size of the buffer, defaultBlockSize if zero
+	closed    bool   // the final block has been written
+	pipeline  bool   // the written block is in flight until the next block is written, see Pipeline
+	spare     []byte // the buffer of the block in flight
+}
+
+type writeBlockFunc func(block []byte, last bool) (n int, err error)
+
+func (b *blockBuffer) reset() {
+	b.size = 0
+	b.closed = false
+}
+
+// bufferSize returns the size of the blocks collected by the buffer.
+func (b *blockBuffer) bufferSize() int {
+	if b.blockSize == 0 {
+		return defaultBlockSize
+	}
+	return b.blockSize
+}
+
+// write buffers the data, a full block is compressed when more data comes,
+// so the last full block can still be the final block on close.
+func (b *blockBuffer) write(data []byte, writeBlock writeBlockFunc) (n int, err error) {
+	if b.closed {
+		return 0, errors.ErrWriterClosed
+	}
+	if b.buf == nil {
+		b.buf = mem.Alloc64ByteAligned(uintptr(b.bufferSize()))
+	}
+	for len(data) > 0 {
+		if b.size == len(b.buf) {
+			_, err = writeBlock(b.buf, false)
+			if err != nil {
+				return n, err
+			}
+			b.next()
+		}
+		m := copy(b.buf[b.size:], data)
+		b.size += m
+		n += m
+		data = data[m:]
+	}
+	return n, nil
+}
+
+// next switches to the buffer of the next block after the pending block has been written.
+func (b *blockBuffer) next() {
+	b.size = 0
+	if !b.pipeline {
+		return
+	}
+	if b.spare == nil {
+		b.spare = mem.Alloc64ByteAligned(uintptr(b.bufferSize()))
+	}
+	b.buf, b.spare = b.spare, b.buf
+}
+
+// flush compresses the pending data, and then writes an empty stored block to align the stream to a byte boundary,
+// so all data written so far can be decompressed by the reader, the same as the flate.Writer.Flush.
+func (b *blockBuffer) flush(writeBlock writeBlockFunc) (err error) {
+	if b.closed {
+		return errors.ErrWriterClosed
+	}
+	if b.size != 0 {
+		_, err = writeBlock(b.buf[:b.size], false)
+		if err != nil {
+			return err
+		}
+		b.next()
+	}
+	_, err = writeBlock(nil, false)
+	return err
+}
+
+// close compresses the pending data as the final block, it does nothing if the final block has been written.
+func (b *blockBuffer) close(writeBlock writeBlockFunc) (err error) {
+	if b.closed {
+		return nil
+	}
+	_, err = writeBlock(b.buf[:b.size], true)
+	b.size = 0
+	return err
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestBlockBuffer(t *testing.T) {
+	tests := []struct {
+		inputs  []writerTestsInput
+		outputs []writerTestsOutput
+	}{
+		{
+			inputs:  []writerTestsInput{{Value: 32 * 1024}},
+			outputs: []writerTestsOutput{{32 * 1024, true}},
+		},
+		{
+			inputs:  []writerTestsInput{{Value: 16 * 1024}, {Value: 16*1024 + 1}},
+			outputs: []writerTestsOutput{{32 * 1024, false}, {1, true}},
+		},
+		{
+			inputs:  []writerTestsInput{{Value: 64*1024 + 2}},
+			outputs: []writerTestsOutput{{32 * 1024, false}, {32 * 1024, false}, {2, true}},
+		},
+		{
+			inputs: []writerTestsInput{{Value: 16 * 1024}, {Action: testFlushAction}, {Value: 16 * 1024}},
+			outputs: []writerTestsOutput{
+				{16 * 1024, false},
+				{0, false},
+				{16 * 1024, true},
+			},
+		},
+	}
+	mbw := &mockBlockWriter{}
+	b := &blockBuffer{}
+	for _, test := range tests {
+		mbw.Reset(nil)
+		b.reset()
+		for _, v := range test.inputs {
+			switch v.Action {
+			case testWriteAction:
+				n, err := b.write(make([]byte, v.Value), mbw.writeBlock)
+				if err != nil || n != v.Value {
+					t.Fatal(n, err)
+				}
+			case testFlushAction:
+				if err := b.flush(mbw.writeBlock); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := b.close(mbw.writeBlock); err != nil {
+			t.Fatal(err)
+		}
+		if outputs := mbw.toWriterTestsOutput(); len(outputs) != len(test.outputs) {
+			t.Fatal(test.outputs, outputs)
+		}
+		for i, output := range mbw.toWriterTestsOutput() {
+			if output != test.outputs[i] {
+				t.Fatal(test.outputs, mbw.toWriterTestsOutput())
+			}
+		}
+		// the writeBlock marks the stream closed
+		b.closed = true
+		if _, err := b.write([]byte{1}, mbw.writeBlock); err != errors.ErrWriterClosed {
+			t.Fatalf("expected writer closed error, got %v", err)
+		}
+		if err := b.close(mbw.writeBlock); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBlockBuffer_Pipeline(t *testing.T) {
+	mbw := &mockBlockWriter{pipeline: true}
+	b := &blockBuffer{pipeline: true}
+	for i := 0; i < 8; i++ {
+		if _, err := b.write(bytes.Repeat([]byte{byte(i)}, 20*1024), mbw.writeBlock); err != nil {
+			t.Fatal(err)
+		}
+		if i == 4 {
+			if err := b.flush(mbw.writeBlock); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := b.close(mbw.writeBlock); err != nil {
+		t.Fatal(err)
+	}
+	if mbw.overwritten {
+		t.Fatal("the block in flight was overwritten")
+	}
+	if len(mbw.records) != 7 {
+		t.Fatal(mbw.toWriterTestsOutput())
+	}
+}
+
+func TestDeflate_Write(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100*1024 + 123))
+	buf := bytes.NewBuffer(nil)
+	d, err := NewDeflate(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.Write(input[:1000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all data written so far can be decompressed after Flush
+	r := flate.NewReader(bytes.NewReader(buf.Bytes()))
+	p := make([]byte, 1000)
+	_, err = io.ReadFull(r, p)
+	if err != nil || !bytes.Equal(p, input[:1000]) {
+		t.Fatal("flushed data is not decompressed", err)
+	}
+	for i := 1000; i < len(input); i += 7000 {
+		end := i + 7000
+		if end > len(input) {
+			end = len(input)
+		}
+		_, err = d.Write(input[i:end])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = d.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Write(input); err != errors.ErrWriterClosed {
+		t.Fatalf("expected writer closed error, got %v", err)
+	}
+	output, err := io.ReadAll(flate.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
+
+func TestGzip_Write(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(100 * 1024))
+	buf := bytes.NewBuffer(nil)
+	g := NewGzip(buf)
+	g.Name = "hallo.txt"
+	_, err := io.Copy(struct{ io.Writer }{g}, bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, input) || r.Name != "hallo.txt" {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
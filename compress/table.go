@@ -0,0 +1,178 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/iaa"
+	"github.com/intel/ixl-go/util/mem"
+)
+
+// HuffmanTable is a canned huffman table used to compress dynamic blocks without the statistic pass.
+//
+// Every literal/length and distance symbol has a code in the table, so any data can be encoded by it,
+// but the compression ratio is only good for the data similar to the sample the table built from.
+//
+// A HuffmanTable is immutable, it can be shared by many Deflate objects across goroutines.
+type HuffmanTable struct {
+	histogram iaa.Histogram // huffman codes in IAA format
+}
+
+// NewHuffmanTable builds a HuffmanTable from the sample data.
+//
+// Only the HuffmanOnly, BusyPoll and PackageMerge options are used,
+// the table built with HuffmanOnly option contains no LZ77 statistics.
+func NewHuffmanTable(sample []byte, opts ...Option) (*HuffmanTable, error) {
+	opt := newOption(opts)
+	if opt.err != nil {
+		return nil, opt.err
+	}
+	tableOpts := []Option{DynamicMode()}
+	if opt.mode == modeHuffmanOnly {
+		tableOpts = append(tableOpts, HuffmanOnly())
+	}
+	if opt.busyPoll {
+		tableOpts = append(tableOpts, BusyPoll())
+	}
+	if opt.packageMerge {
+		tableOpts = append(tableOpts, PackageMerge())
+	}
+	d, err := NewDeflate(nil, tableOpts...)
+	if err != nil {
+		return nil, err
+	}
+	t := &HuffmanTable{}
+	err = d.buildTable(sample, t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CannedTable makes the Deflate compress the dynamic blocks using the canned huffman table,
+// the statistic pass of every block is skipped, so only one job is submitted per block.
+//
+// The table is only used by the DynamicMode and the HuffmanOnly mode.
+func CannedTable(t *HuffmanTable) Option {
+	return func(opt *option) {
+		if t == nil {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.table = t
+	}
+}
+
+// ReuseTable makes the Deflate reuse the huffman table across the blocks.
+//
+// The table is built from the first block (or the CannedTable is used if specified),
+// and it's rebuilt from the next block when the compression ratio of a block
+// drops more than the threshold (0 < threshold < 1) compared with the first block encoded by the table.
+// e.g. threshold 0.1 means the table is rebuilt when the ratio degrades by 10%.
+func ReuseTable(threshold float64) Option {
+	return func(opt *option) {
+		if threshold <= 0 || threshold >= 1 {
+			opt.err = errors.InvalidArgument
+			return
+		}
+		opt.reuseTable = true
+		opt.rebuildThreshold = threshold
+	}
+}
+
+// minRatioBlockSize is the min size of the block used to check the compression ratio,
+// the ratio of a small block is not stable.
+const minRatioBlockSize = 4 * 1024
+
+// Table returns a copy of the huffman table currently used by the Deflate,
+// which can be shared with other Deflate objects by the CannedTable option.
+// It returns nil if no table is used yet.
+func (d *Deflate) Table() *HuffmanTable {
+	if d.table == nil {
+		return nil
+	}
+	t := *d.table
+	return &t
+}
+
+// resetTable reverts the table to the canned table.
+func (d *Deflate) resetTable() {
+	d.table = d.cannedTable
+	d.tableRatio = 0
+	d.tableExpired = false
+}
+
+// buildTable runs the statistic pass on the data, and generates huffman codes for all symbols into the table.
+func (d *Deflate) buildTable(data []byte, t *HuffmanTable) error {
+	if d.estimateHist == nil {
+		d.estimateHist = mem.Alloc64Align[iaa.Histogram]()
+	}
+	t.histogram = iaa.Histogram{}
+	for len(data) > 0 {
+		size := len(data)
+		if size > defaultBlockSize {
+			size = defaultBlockSize
+		}
+		histogram := d.estimateHist
+		*histogram = iaa.Histogram{}
+		err := d.statisticBlock(data[:size], histogram)
+		if err != nil {
+			return err
+		}
+		for i, c := range histogram.LiteralCodes {
+			t.histogram.LiteralCodes[i] += c
+		}
+		for i, c := range histogram.DistanceCodes {
+			t.histogram.DistanceCodes[i] += c
+		}
+		data = data[size:]
+	}
+	// every symbol must have a code, then the table can encode any block
+	for i := range t.histogram.LiteralCodes {
+		t.histogram.LiteralCodes[i]++
+	}
+	for i := range t.histogram.DistanceCodes {
+		t.histogram.DistanceCodes[i]++
+	}
+	d.generateCodes(&t.histogram)
+	return nil
+}
+
+// writeTableBlock compresses the block using the reused huffman table.
+func (d *Deflate) writeTableBlock(block []byte, last bool) (n int, err error) {
+	if d.table == nil || d.tableExpired {
+		if d.ownTable == nil {
+			d.ownTable = &HuffmanTable{}
+		}
+		err = d.buildTable(block, d.ownTable)
+		if err != nil {
+			return 0, err
+		}
+		d.table = d.ownTable
+		d.tableRatio = 0
+		d.tableExpired = false
+	}
+
+	aecs := &d.aecs[d.toggle]
+	aecs.Reset()
+	aecs.Histogram = d.table.histogram
+	d.descriptor.Reset()
+	d.completionRecord.Reset()
+	headerBits := d.writeHeader(&aecs.Histogram, &aecs.OutputAccumulatorData, last)
+	err = d.encodeBlock(aecs, block, last, headerBits)
+	if err != nil {
+		return 0, err
+	}
+	d.toggle ^= 1
+
+	if d.reuseTable && len(block) >= minRatioBlockSize && d.encodedSize > 0 {
+		ratio := float64(len(block)) / float64(d.encodedSize)
+		if d.tableRatio == 0 {
+			d.tableRatio = ratio
+		} else if ratio < d.tableRatio*(1-d.rebuildThreshold) {
+			d.tableExpired = true
+		}
+	}
+	return len(block), nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestTableOptions(t *testing.T) {
+	for _, opt := range []Option{CannedTable(nil), ReuseTable(0), ReuseTable(1), ReuseTable(-0.5)} {
+		if err := newOption([]Option{opt}).err; err != errors.InvalidArgument {
+			t.Fatalf("expected invalid argument error, got %v", err)
+		}
+	}
+	opt := newOption([]Option{ReuseTable(0.2)})
+	if opt.err != nil || !opt.reuseTable || opt.rebuildThreshold != 0.2 {
+		t.Fatalf("unexpected option: %+v", opt)
+	}
+}
+
+func TestCannedTable(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	table, err := NewHuffmanTable([]byte(testutil.RandomText(64 * 1024)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	random := make([]byte, 10*1024)
+	_, _ = rand.Read(random)
+	inputs := [][]byte{
+		[]byte(testutil.RandomText(200 * 1024)),
+		// the symbols not in the sample can be encoded too
+		random,
+		{},
+	}
+	for _, opts := range [][]Option{
+		{CannedTable(table)},
+		{CannedTable(table), HuffmanOnly()},
+		{ReuseTable(0.1)},
+		{CannedTable(table), ReuseTable(0.1)},
+	} {
+		d, err := NewDeflate(nil, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range inputs {
+			buf := bytes.NewBuffer(nil)
+			d.Reset(buf)
+			_, err = d.ReadFrom(bytes.NewReader(input))
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(flate.NewReader(buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, input) {
+				t.Fatal("decompressed data is not consistent with input")
+			}
+		}
+	}
+}
+
+func TestReuseTable_Rebuild(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	d, err := NewDeflate(io.Discard, ReuseTable(0.1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []byte(testutil.RandomText(defaultBlockSize * 2))
+	_, err = d.writeBlock(text[:defaultBlockSize], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := d.Table()
+	if first == nil {
+		t.Fatal("the table should be built from the first block")
+	}
+	_, err = d.writeBlock(text[defaultBlockSize:], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.tableExpired {
+		t.Fatal("the table should be reused for similar data")
+	}
+	// the ratio degrades a lot
+	random := make([]byte, defaultBlockSize)
+	_, _ = rand.Read(random)
+	_, err = d.writeBlock(random, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.tableExpired {
+		t.Fatal("the table should be expired")
+	}
+	_, err = d.writeBlock(random, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Table().histogram == first.histogram {
+		t.Fatal("the table should be rebuilt")
+	}
+}
+
+func BenchmarkCannedTable(b *testing.B) {
+	if !Ready() {
+		b.Skip("IAA devices not found")
+	}
+	text := []byte(testutil.RandomText(1024 * 1024))
+	table, _ := NewHuffmanTable(text[:64*1024])
+	d, _ := NewDeflate(io.Discard, CannedTable(table))
+	b.SetBytes(int64(len(text)))
+	for j := 0; j < b.N; j++ {
+		d.Reset(io.Discard)
+		_, _ = d.CompressAll(nil, text)
+	}
+}
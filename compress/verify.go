@@ -0,0 +1,125 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import "github.com/intel/ixl-go/util/mem"
+
+// Verify makes every compressed block verified before it's written out:
+// the block is decompressed by the device, and the CRC-32 of the decompressed data
+// is compared with the CRC-32 of the source data calculated by the compression job.
+// If they mismatch, the block is written as a stored block instead, and the failure is counted (see VerifyFailures).
+//
+// Verification costs one more job per block.
+// It can't be used with a preset dictionary, as the data is compressed by CPU then (see NewDeflateDict).
+func Verify() Option {
+	return func(opt *option) {
+		opt.verify = true
+	}
+}
+
+// VerifyFailures returns the number of blocks failed to verify since the Deflate created or reset,
+// see Verify option.
+func (d *Deflate) VerifyFailures() int64 {
+	return d.verifyFailures
+}
+
+// VerifyFailures returns the number of blocks failed to verify since the Gzip created or reset,
+// see Verify option.
+func (g *Gzip) VerifyFailures() int64 {
+	if g.compressor == nil {
+		return 0
+	}
+	return g.compressor.VerifyFailures()
+}
+
+// VerifyFailures returns the number of blocks failed to verify since the ParallelGzip created or reset,
+// see Verify option.
+func (p *ParallelGzip) VerifyFailures() int64 {
+	return p.verifyFailures
+}
+
+// newVerifier creates the decompressor used to verify the blocks.
+func (d *Deflate) newVerifier() (err error) {
+	var opts []Option
+	if d.busyPoll {
+		opts = append(opts, BusyPoll())
+	}
+	d.verifier, err = NewInflate(nil, opts...)
+	if err != nil {
+		return err
+	}
+	d.verifyOutput = mem.Alloc64ByteAligned(uintptr(d.blockSize))
+	return nil
+}
+
+// verifyBlock decompresses the block just compressed into d.output, and checks the CRC-32 of it.
+// The `prev` is the CRC-32 before compressing the block.
+func (d *Deflate) verifyBlock(block []byte, prev uint32) bool {
+	cr := d.completionRecord
+	bits := uint64(cr.OutputSize) * 8
+	if cr.OutputBits != 0 {
+		bits = bits - 8 + uint64(cr.OutputBits)
+	}
+	// skip the bits of the prev block, and make the block a final block.
+	if d.verifyHook != nil {
+		d.verifyHook(d.output[:cr.OutputSize])
+	}
+	d.verifyInput.reset()
+	d.verifyInput.appendBits(d.output[:cr.OutputSize], uint64(d.bitsNum), bits)
+	d.verifyInput.buf[0] |= 1
+
+	output := d.verifyOutput[:len(block)]
+	v := d.verifier
+	v.Reset(nil)
+	n, err := v.DecompressAll(d.verifyInput.buf, output)
+	if err != nil || n != len(block) {
+		return false
+	}
+	return crc32Combine(prev, v.cr.CRC, int64(len(block))) == cr.CRC
+}
+
+// bitAppender appends bits into a byte slice, the bits are packed starting with the least-significant bit.
+type bitAppender struct {
+	buf  []byte
+	bits uint64 // number of bits in buf
+}
+
+func (b *bitAppender) reset() {
+	b.buf = b.buf[:0]
+	b.bits = 0
+}
+
+// appendBits appends the bits [from, to) of src.
+func (b *bitAppender) appendBits(src []byte, from, to uint64) {
+	for from < to {
+		n := to - from
+		if n > 8 {
+			n = 8
+		}
+		shift := from % 8
+		v := uint16(src[from/8]) >> shift
+		if shift+n > 8 {
+			v |= uint16(src[from/8+1]) << (8 - shift)
+		}
+		b.writeBits(v&(1<<n-1), n)
+		from += n
+	}
+}
+
+func (b *bitAppender) writeBits(v uint16, n uint64) {
+	for n > 0 {
+		used := b.bits % 8
+		if used == 0 {
+			b.buf = append(b.buf, 0)
+		}
+		k := 8 - used
+		if k > n {
+			k = n
+		}
+		b.buf[len(b.buf)-1] |= byte(v&(1<<k-1)) << used
+		v >>= k
+		n -= k
+		b.bits += k
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestVerify(t *testing.T) {
+	g := NewGzip(nil)
+	if g.VerifyFailures() != 0 {
+		t.Fatal("expected no failures before compression")
+	}
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(300 * 1024))
+	for _, opt := range []Option{DynamicMode(), FixedMode(), HuffmanOnly()} {
+		buf := bytes.NewBuffer(nil)
+		g := NewGzip(buf, opt, Verify())
+		_, err := g.ReadFrom(bytes.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = g.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.VerifyFailures() != 0 {
+			t.Fatalf("unexpected verify failures: %d", g.VerifyFailures())
+		}
+		r, err := gzip.NewReader(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(output, input) {
+			t.Fatal("decompressed data is not consistent with input")
+		}
+	}
+}
+
+func TestVerify_Failure(t *testing.T) {
+	if _, err := NewDeflateDict(nil, []byte("dictionary"), Verify()); err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	input := []byte(testutil.RandomText(300 * 1024))
+	for _, opts := range [][]Option{{Verify()}, {Verify(), Pipeline()}} {
+		buf := bytes.NewBuffer(nil)
+		d, err := NewDeflate(buf, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// corrupt the compressed blocks, so they are written as stored blocks
+		d.verifyHook = func(output []byte) {
+			output[len(output)/2] ^= 0xff
+		}
+		if _, err = d.ReadFrom(bytes.NewReader(input)); err != nil {
+			t.Fatal(err)
+		}
+		if err = d.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if d.VerifyFailures() == 0 {
+			t.Fatal("expected verify failures")
+		}
+		output, err := io.ReadAll(flate.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(output, input) {
+			t.Fatal("decompressed data is not consistent with input")
+		}
+		d.Reset(io.Discard)
+		if d.VerifyFailures() != 0 {
+			t.Fatalf("expected no failures after reset, got %d", d.VerifyFailures())
+		}
+	}
+}
+
+func TestBitAppender(t *testing.T) {
+	src := []byte{0b10110011, 0b01011100, 0b11110000}
+	var b bitAppender
+	// the bits are listed from the least-significant bit
+	b.appendBits(src, 1, 4)   // 100
+	b.appendBits(src, 6, 17)  // 01 00111010 0
+	b.appendBits(src, 20, 24) // 1111
+	if b.bits != 18 {
+		t.Fatalf("expected 18 bits, got %d", b.bits)
+	}
+	expected := []byte{0b10010001, 0b11001011, 0b11}
+	if !bytes.Equal(b.buf, expected) {
+		t.Fatalf("expected %08b, got %08b", expected, b.buf)
+	}
+}
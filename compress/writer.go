@@ -14,23 +14,39 @@ var _ io.WriteCloser = &BufWriter{}
 // BufWriter is a buffer writer for wrapping Deflate/Gzip as a io.Writer.
 type BufWriter struct {
 	buffer []byte
+	spare  []byte // the buffer of the block in flight, only used in the pipelined mode
 	offset int
 	bw     blockWriter
 }
 
 type blockWriter interface {
 	writeBlock(block []byte, last bool) (n int, err error)
+	bufferSize() int   // the max size of the blocks
+	pipelined() bool   // the written block is in flight until the next block is written
+	writer() io.Writer // the underlying writer
 	Reset(w io.Writer)
 	Close() error
 }
 
 // NewWriter create a new BufWriter.
-// The argument should be Gzip or Deflate.
+// The argument should be Gzip, Zlib or Deflate, the size of the buffer is the block size of it, see BlockSize.
 func NewWriter(bw blockWriter) *BufWriter {
-	return &BufWriter{
-		buffer: mem.Alloc64ByteAligned(maxBlockSize),
+	w := &BufWriter{
+		buffer: mem.Alloc64ByteAligned(uintptr(bw.bufferSize())),
 		bw:     bw,
 	}
+	if bw.pipelined() {
+		w.spare = mem.Alloc64ByteAligned(uintptr(bw.bufferSize()))
+	}
+	return w
+}
+
+// next switches to the buffer of the next block after a block has been written.
+func (w *BufWriter) next() {
+	w.offset = 0
+	if w.spare != nil {
+		w.buffer, w.spare = w.spare, w.buffer
+	}
 }
 
 // Reset writer.
@@ -43,18 +59,18 @@ func (w *BufWriter) Reset(writer io.Writer) {
 func (w *BufWriter) Write(data []byte) (n int, err error) {
 	size := len(data)
 CONSUME:
-	if w.offset+len(data) < maxBlockSize {
+	if w.offset+len(data) < len(w.buffer) {
 		copy(w.buffer[w.offset:], data)
 		w.offset += len(data)
 		return size, nil
 	}
 
 	copy(w.buffer[w.offset:], data)
-	if w.offset+len(data) >= maxBlockSize {
-		copiedSize := maxBlockSize - w.offset
+	if w.offset+len(data) >= len(w.buffer) {
+		copiedSize := len(w.buffer) - w.offset
 		data = data[copiedSize:]
-		w.offset = 0
 		_, err := w.bw.writeBlock(w.buffer, false)
+		w.next()
 		if err != nil {
 			return 0, err
 		}
@@ -64,24 +80,34 @@ CONSUME:
 }
 
 // Flush immediately write all buffered data to underlying block writer.
+// In the pipelined mode (see Pipeline), the block in flight is finished by an empty block like Deflate.Flush,
+// so all data has been written to the underlying writer when Flush returns.
 func (w *BufWriter) Flush() error {
-	_, err := w.bw.writeBlock(w.buffer[:w.offset], false)
-	w.offset = 0
+	block := w.buffer[:w.offset]
+	_, err := w.bw.writeBlock(block, false)
+	w.next()
+	if err == nil && len(block) != 0 && w.bw.pipelined() {
+		_, err = w.bw.writeBlock(nil, false)
+	}
 	return err
 }
 
-// Close flush all buffered data to underlying block writer and close it.
+// Close flush all buffered data to underlying block writer as the final block and close the block writer,
+// then the underlying writer of the block writer is closed if it implements io.Closer.
+//
+// Notice: unlike BufWriter, the Close of Deflate, Gzip and Zlib does not close the underlying writer.
 func (w *BufWriter) Close() error {
 	_, err := w.bw.writeBlock(w.buffer[:w.offset], true)
 	w.offset = 0
-	if err != nil {
-		uerr := w.bw.Close()
-		if uerr != nil {
-			return uerr
+	if uerr := w.bw.Close(); uerr != nil {
+		err = uerr
+	}
+	if closer, ok := w.bw.writer().(io.Closer); ok {
+		if cerr := closer.Close(); cerr != nil && err == nil {
+			err = cerr
 		}
-		return err
 	}
-	return w.bw.Close()
+	return err
 }
 
 // NewDeflateWriter create a deflate writer
@@ -97,3 +123,8 @@ func NewDeflateWriter(w io.Writer, opts ...Option) (*BufWriter, error) {
 func NewGzipWriter(w io.Writer, opts ...Option) *BufWriter {
 	return NewWriter(NewGzip(w, opts...))
 }
+
+// NewZlibWriter create a zlib writer
+func NewZlibWriter(w io.Writer, opts ...Option) *BufWriter {
+	return NewWriter(NewZlib(w, opts...))
+}
@@ -27,8 +27,9 @@ type writerTestsInput struct {
 }
 
 var writerTests = []struct {
-	inputs  []writerTestsInput
-	outputs []writerTestsOutput
+	blockSize int
+	inputs    []writerTestsInput
+	outputs   []writerTestsOutput
 }{
 	{
 		inputs: []writerTestsInput{
@@ -92,12 +93,23 @@ var writerTests = []struct {
 			{2, true},
 		},
 	},
+	{
+		blockSize: 64 * 1024,
+		inputs: []writerTestsInput{
+			{Value: 32 * 1024},
+			{Value: 64*1024 + 2},
+		},
+		outputs: []writerTestsOutput{
+			{64 * 1024, false},
+			{32*1024 + 2, true},
+		},
+	},
 }
 
 func TestWriter_Write(t *testing.T) {
-	mbw := &mockBlockWriter{}
-	w := NewWriter(mbw)
 	for _, test := range writerTests {
+		mbw := &mockBlockWriter{blockSize: test.blockSize}
+		w := NewWriter(mbw)
 		w.Reset(nil)
 		for _, v := range test.inputs {
 			switch v.Action {
@@ -121,6 +133,49 @@ func TestWriter_Write(t *testing.T) {
 	}
 }
 
+func TestWriter_WritePipelined(t *testing.T) {
+	mbw := &mockBlockWriter{pipeline: true}
+	w := NewWriter(mbw)
+	w.Reset(nil)
+	for i := 0; i < 8; i++ {
+		_, _ = w.Write(bytes.Repeat([]byte{byte(i)}, 20*1024))
+		if i == 4 {
+			_ = w.Flush()
+		}
+	}
+	w.Close()
+	if mbw.overwritten {
+		t.Fatal("the block in flight was overwritten")
+	}
+	// the block in flight is finished by an empty block on Flush
+	if len(mbw.records) != 7 || len(mbw.records[4].data) != 0 {
+		t.Fatal(mbw.toWriterTestsOutput())
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWriter_CloseUnderlying(t *testing.T) {
+	out := &closeRecorder{}
+	w := NewWriter(&mockBlockWriter{})
+	w.Reset(out)
+	_, _ = w.Write([]byte("hello"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !out.closed {
+		t.Fatal("the underlying writer should be closed by BufWriter")
+	}
+}
+
 func FuzzWriterWrite(f *testing.F) {
 	if !Ready() {
 		f.Skip("no IAA device detected")
@@ -151,10 +206,15 @@ func FuzzWriterWrite(f *testing.F) {
 type mockBlockWriterRecord struct {
 	data []byte
 	last bool
+	copy []byte // copy of the data, used to check the block in flight is not modified
 }
 
 type mockBlockWriter struct {
-	records []mockBlockWriterRecord
+	w           io.Writer
+	records     []mockBlockWriterRecord
+	blockSize   int
+	pipeline    bool
+	overwritten bool // the block in flight was modified before the next block is written
 }
 
 func (b *mockBlockWriter) toWriterTestsOutput() (outputs []writerTestsOutput) {
@@ -168,11 +228,30 @@ func (b *mockBlockWriter) toWriterTestsOutput() (outputs []writerTestsOutput) {
 }
 
 func (b *mockBlockWriter) writeBlock(block []byte, last bool) (n int, err error) {
-	b.records = append(b.records, mockBlockWriterRecord{data: block, last: last})
+	if n := len(b.records); b.pipeline && n > 0 && !bytes.Equal(b.records[n-1].data, b.records[n-1].copy) {
+		b.overwritten = true
+	}
+	b.records = append(b.records, mockBlockWriterRecord{data: block, last: last, copy: append([]byte{}, block...)})
 	return len(block), nil
 }
 
+func (b *mockBlockWriter) bufferSize() int {
+	if b.blockSize == 0 {
+		return defaultBlockSize
+	}
+	return b.blockSize
+}
+
+func (b *mockBlockWriter) pipelined() bool {
+	return b.pipeline
+}
+
+func (b *mockBlockWriter) writer() io.Writer {
+	return b.w
+}
+
 func (b *mockBlockWriter) Reset(w io.Writer) {
+	b.w = w
 	b.records = nil
 }
 
@@ -0,0 +1,268 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package zipflate provides the compressor and decompressor of the Deflate method of archive/zip using IAA.
+//
+// The archive/zip registers the Deflate method globally and panics on the registration of the same method,
+// so the IAA compressor and decompressor are registered per zip.Writer and zip.Reader:
+//
+//	w := zip.NewWriter(file)
+//	zipflate.RegisterWriter(w)
+//
+// The compress/flate of the standard library is used as the fallback when the device is busy
+// (see MaxStreams) or not found. The entries not compressed by IAA may use a 32KB history window,
+// which is larger than the 4KB window supported by IAA, they are decompressed by the fallback too.
+package zipflate
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+)
+
+type config struct {
+	maxStreams int
+	opts       []compress.Option
+	err        error
+}
+
+// Option configures the Codec.
+type Option func(c *config)
+
+// MaxStreams sets the max number of the entries compressed or decompressed by IAA concurrently,
+// the device is considered busy if more entries are being processed, and the fallback is used for them.
+// The default value is the number of CPUs.
+func MaxStreams(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.err = errors.InvalidArgument
+			return
+		}
+		c.maxStreams = n
+	}
+}
+
+// CompressOptions sets the options used to create the Deflate and Inflate.
+func CompressOptions(opts ...compress.Option) Option {
+	return func(c *config) {
+		c.opts = opts
+	}
+}
+
+// Codec creates the compressors and decompressors, the IAA objects are pooled.
+// A Codec is safe for concurrent use by multiple goroutines.
+type Codec struct {
+	pool    *compress.Pool
+	streams chan struct{}
+	writers sync.Pool // *flate.Writer of the fallback
+}
+
+// New creates a new Codec.
+func New(opts ...Option) (*Codec, error) {
+	c := &config{maxStreams: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Codec{
+		pool:    compress.NewPool(c.opts...),
+		streams: make(chan struct{}, c.maxStreams),
+	}, nil
+}
+
+var defaultCodec, _ = New()
+
+// RegisterWriter registers the compressor of the default Codec for the Deflate method on the zip.Writer.
+func RegisterWriter(w *zip.Writer) {
+	defaultCodec.RegisterWriter(w)
+}
+
+// RegisterReader registers the decompressor of the default Codec for the Deflate method on the zip.Reader.
+func RegisterReader(r *zip.Reader) {
+	defaultCodec.RegisterReader(r)
+}
+
+// RegisterWriter registers the compressor for the Deflate method on the zip.Writer.
+func (c *Codec) RegisterWriter(w *zip.Writer) {
+	w.RegisterCompressor(zip.Deflate, c.Compressor)
+}
+
+// RegisterReader registers the decompressor for the Deflate method on the zip.Reader.
+func (c *Codec) RegisterReader(r *zip.Reader) {
+	r.RegisterDecompressor(zip.Deflate, c.Decompressor)
+}
+
+// acquire reserves a stream of the device, it returns false if the device is busy or not found.
+func (c *Codec) acquire() bool {
+	if !compress.Ready() {
+		return false
+	}
+	select {
+	case c.streams <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Codec) release() {
+	<-c.streams
+}
+
+// Compressor is a zip.Compressor compressing the entry by IAA, or by compress/flate if the device is busy.
+func (c *Codec) Compressor(w io.Writer) (io.WriteCloser, error) {
+	if c.acquire() {
+		d, err := c.pool.GetDeflate(w)
+		if err == nil {
+			return &deflateWriter{Deflate: d, c: c}, nil
+		}
+		c.release()
+	}
+	if fw, ok := c.writers.Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return &flateWriter{Writer: fw, c: c}, nil
+	}
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &flateWriter{Writer: fw, c: c}, nil
+}
+
+// Decompressor is a zip.Decompressor decompressing the entry by IAA, or by compress/flate if the device is busy.
+// The entry is decompressed by compress/flate again if IAA fails to decompress it.
+func (c *Codec) Decompressor(r io.Reader) io.ReadCloser {
+	if c.acquire() {
+		i, err := c.pool.GetInflate(r)
+		if err == nil {
+			return &inflateReader{Inflate: i, r: r, c: c}
+		}
+		c.release()
+	}
+	return flate.NewReader(r)
+}
+
+// deflateWriter puts the Deflate back into the pool on close.
+type deflateWriter struct {
+	*compress.Deflate
+	c *Codec
+}
+
+func (w *deflateWriter) Close() error {
+	if w.Deflate == nil {
+		return errors.ErrWriterClosed
+	}
+	err := w.Deflate.Close()
+	w.c.pool.PutDeflate(w.Deflate)
+	w.Deflate = nil
+	w.c.release()
+	return err
+}
+
+// flateWriter puts the flate.Writer back into the pool on close.
+type flateWriter struct {
+	*flate.Writer
+	c *Codec
+}
+
+func (w *flateWriter) Close() error {
+	if w.Writer == nil {
+		return errors.ErrWriterClosed
+	}
+	err := w.Writer.Close()
+	w.c.writers.Put(w.Writer)
+	w.Writer = nil
+	return err
+}
+
+// inflateReader decompresses the entry by IAA,
+// and falls back to compress/flate if IAA fails and the entry can be read again.
+type inflateReader struct {
+	*compress.Inflate
+	r        io.Reader
+	c        *Codec
+	read     int64         // bytes returned
+	fallback io.ReadCloser // the flate reader after falling back
+	err      error         // the entry can't be read after failing to fall back
+}
+
+func (r *inflateReader) Read(p []byte) (n int, err error) {
+	if r.fallback != nil {
+		return r.fallback.Read(p)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.Inflate == nil {
+		return 0, io.ErrClosedPipe
+	}
+	n, err = r.Inflate.Read(p)
+	r.read += int64(n)
+	if canFallBack(err) {
+		if ferr := r.fallBack(); ferr == nil {
+			return n, nil
+		}
+		if r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// canFallBack reports whether compress/flate may decompress the data IAA fails to decompress,
+// the limit errors are reported as they are.
+func canFallBack(err error) bool {
+	if _, ok := err.(*errors.LimitError); ok {
+		return false
+	}
+	_, ok := err.(errors.Error)
+	return ok
+}
+
+// fallBack decompresses the entry from the beginning by compress/flate, the bytes returned are skipped.
+// Once the entry is rewound, the reader fails with the error of the fallback if it fails,
+// instead of decompressing the rewound entry by IAA.
+func (r *inflateReader) fallBack() error {
+	seeker, ok := r.r.(io.Seeker)
+	if !ok {
+		return errors.InvalidArgument
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r.release()
+	fr := flate.NewReader(r.r)
+	if _, err := io.CopyN(io.Discard, fr, r.read); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		_ = fr.Close()
+		r.err = err
+		return err
+	}
+	r.fallback = fr
+	return nil
+}
+
+func (r *inflateReader) release() {
+	if r.Inflate != nil {
+		r.c.pool.PutInflate(r.Inflate)
+		r.Inflate = nil
+		r.c.release()
+	}
+}
+
+func (r *inflateReader) Close() error {
+	r.release()
+	if r.fallback != nil {
+		return r.fallback.Close()
+	}
+	return nil
+}
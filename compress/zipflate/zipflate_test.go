@@ -0,0 +1,139 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zipflate
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/intel/ixl-go/compress"
+	"github.com/intel/ixl-go/errors"
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func writeZip(t *testing.T, files [][]byte, register func(w *zip.Writer)) []byte {
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	if register != nil {
+		register(w)
+	}
+	for i, data := range files {
+		f, err := w.Create("file" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checkZip(t *testing.T, archive []byte, files [][]byte, register func(r *zip.Reader)) {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if register != nil {
+		register(r)
+	}
+	if len(r.File) != len(files) {
+		t.Fatalf("expected %d files, got %d", len(files), len(r.File))
+	}
+	for i, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, files[i]) {
+			t.Fatalf("unexpected data of %s", f.Name)
+		}
+	}
+}
+
+func testFiles() [][]byte {
+	return [][]byte{
+		[]byte(testutil.RandomText(200 * 1024)),
+		[]byte("hello"),
+		{},
+		[]byte(testutil.RandomText(50 * 1024)),
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(MaxStreams(0))
+	if err != errors.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	files := testFiles()
+	// IAA, or the fallback if no device found
+	archive := writeZip(t, files, RegisterWriter)
+	checkZip(t, archive, files, nil)
+	checkZip(t, archive, files, RegisterReader)
+
+	// entries compressed by compress/flate
+	archive = writeZip(t, files, nil)
+	checkZip(t, archive, files, RegisterReader)
+}
+
+func TestCodec_Busy(t *testing.T) {
+	if !compress.Ready() {
+		t.Skip("IAA devices not found")
+	}
+	c, err := New(MaxStreams(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1, err := c.Compressor(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w1.(*deflateWriter); !ok {
+		t.Fatal("expected IAA compressor")
+	}
+	w2, err := c.Compressor(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w2.(*flateWriter); !ok {
+		t.Fatal("expected fallback compressor when the device is busy")
+	}
+	_ = w2.Close()
+	_ = w1.Close()
+	w3, _ := c.Compressor(io.Discard)
+	if _, ok := w3.(*deflateWriter); !ok {
+		t.Fatal("expected IAA compressor after the stream released")
+	}
+	_ = w3.Close()
+}
+
+func TestInflateReader_FallBack(t *testing.T) {
+	if canFallBack(&errors.LimitError{}) || !canFallBack(errors.ErrCorruptedData) || canFallBack(io.EOF) {
+		t.Fatal("unexpected fallback condition")
+	}
+	// the entry is rewound but can't be decompressed by compress/flate
+	r := &inflateReader{r: bytes.NewReader([]byte{0xff, 0xff}), read: 10}
+	if err := r.fallBack(); err == nil {
+		t.Fatal("expected the fallback to fail")
+	}
+	if _, err := r.Read(make([]byte, 10)); err == nil || err != r.err {
+		t.Fatalf("expected the error of the fallback, got %v", err)
+	}
+}
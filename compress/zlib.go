@@ -0,0 +1,218 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/adler32"
+	"io"
+)
+
+// Zlib is an object to hold the state for compress data using zlib format.
+//
+// Notice: the Adler-32 checksum is calculated by CPU, IAA does not support it.
+type Zlib struct {
+	wroteHeader bool
+	level       int
+	w           io.Writer
+	buf         []byte
+	digest      hash.Hash32
+	opts        []Option
+	dict        []byte
+	compressor  *Deflate
+	stream      blockBuffer
+}
+
+// zlib format: https://www.rfc-editor.org/rfc/rfc1950#page-4
+const (
+	zlibDeflate   = 8
+	zlibMaxWindow = 7
+	zlibFDICT     = 1 << 5
+)
+
+// NewZlib create a new Zlib.
+func NewZlib(w io.Writer, opts ...Option) *Zlib {
+	opt := newOption(opts)
+	z := &Zlib{
+		w:      w,
+		opts:   opts,
+		level:  opt.level,
+		digest: adler32.New(),
+	}
+	z.stream.blockSize = opt.blockSize
+	z.stream.pipeline = opt.pipeline
+	return z
+}
+
+func (z *Zlib) bufferSize() int {
+	return z.stream.bufferSize()
+}
+
+func (z *Zlib) pipelined() bool {
+	return z.stream.pipeline
+}
+
+func (z *Zlib) writer() io.Writer {
+	return z.w
+}
+
+// NewZlibDict is like NewZlib but uses a preset dictionary,
+// the dictionary identifier is written into the header (FDICT).
+//
+// Notice: the data is compressed by CPU (compress/flate) when a dictionary is used,
+// and only the last 4KB of the dictionary is used, see NewDeflateDict.
+// The dictionary identifier is the checksum of the whole dictionary.
+func NewZlibDict(w io.Writer, dict []byte, opts ...Option) *Zlib {
+	z := NewZlib(w, opts...)
+	if len(dict) != 0 {
+		z.dict = dict
+	}
+	return z
+}
+
+// appendZlibHeader appends the zlib header |CMF|FLG|[DICTID]| into buf.
+func appendZlibHeader(buf []byte, level int, dict []byte) []byte {
+	cmf := byte(zlibMaxWindow<<4 | zlibDeflate)
+	var flg byte
+	// FLEVEL, same as the compress/zlib
+	switch level {
+	case HuffmanOnlyLevel, NoCompression, BestSpeed:
+		flg = 0 << 6
+	case 2, 3, 4, 5:
+		flg = 1 << 6
+	case 6, DefaultCompression:
+		flg = 2 << 6
+	case 7, 8, 9:
+		flg = 3 << 6
+	}
+	if dict != nil {
+		flg |= zlibFDICT
+	}
+	// FCHECK: (CMF*256 + FLG) must be a multiple of 31
+	flg += uint8(31 - (uint16(cmf)<<8+uint16(flg))%31)
+	buf = append(buf, cmf, flg)
+	if dict != nil {
+		buf = binary.BigEndian.AppendUint32(buf, adler32.Checksum(dict))
+	}
+	return buf
+}
+
+func (z *Zlib) writeHeader() (err error) {
+	z.buf = appendZlibHeader(z.buf[:0], z.level, z.dict)
+	_, err = z.w.Write(z.buf)
+	return err
+}
+
+func (z *Zlib) init() (err error) {
+	if !z.wroteHeader {
+		err = z.writeHeader()
+		if err != nil {
+			return err
+		}
+		z.wroteHeader = true
+	}
+	if z.compressor == nil {
+		z.compressor, err = NewDeflateDict(z.w, z.dict, z.opts...)
+	}
+	return err
+}
+
+// writeBlock compresses the block and writes it to underlying writer.
+//
+// Notice:
+//  1. The block first byte address must be aligned to a multiple of 64 bytes.
+//     You can use `mem.Alloc64ByteAligned` function to alloc a 64 bytes aligned bytes.
+//  2. The `last` argument must be true if the block is the last block in the stream.
+//  3. For most scenarios, you should use the `ReadFrom` method.
+func (z *Zlib) writeBlock(block []byte, last bool) (n int, err error) {
+	if last {
+		z.stream.closed = true
+	}
+	err = z.init()
+	if err != nil {
+		return 0, err
+	}
+	_, _ = z.digest.Write(block)
+	n, err = z.compressor.writeBlock(block, last)
+	if err != nil {
+		return n, err
+	}
+	if last {
+		err = z.writeTailer()
+	}
+	return n, err
+}
+
+// ReadFrom reads all data from `r` and compresses the data and then writes compressed data into underlying writer `w`.
+func (z *Zlib) ReadFrom(reader io.Reader) (n int64, err error) {
+	err = z.init()
+	if err != nil {
+		return 0, err
+	}
+	z.stream.closed = true
+	n, err = z.compressor.ReadFrom(io.TeeReader(reader, z.digest))
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	err = z.writeTailer()
+	return n, err
+}
+
+func (z *Zlib) writeTailer() error {
+	z.buf = binary.BigEndian.AppendUint32(z.buf[:0], z.digest.Sum32())
+	_, err := z.w.Write(z.buf)
+	return err
+}
+
+// CompressAll compresses all data in src as a zlib stream and appends the result to dst.
+// The src may be larger than the device's max transfer size.
+//
+// No memory is allocated if dst has enough capacity, see CompressBound.
+func (z *Zlib) CompressAll(dst, src []byte) (_ []byte, err error) {
+	if z.compressor == nil {
+		z.compressor, err = NewDeflateDict(z.w, z.dict, z.opts...)
+		if err != nil {
+			return dst, err
+		}
+	}
+	dst = appendZlibHeader(dst, z.level, z.dict)
+	dst, err = z.compressor.CompressAll(dst, src)
+	if err != nil {
+		return dst, err
+	}
+	return binary.BigEndian.AppendUint32(dst, adler32.Checksum(src)), nil
+}
+
+// Reset the internal states for reusing the object.
+func (z *Zlib) Reset(w io.Writer) {
+	z.w = w
+	z.wroteHeader = false
+	z.stream.reset()
+	z.digest.Reset()
+	if z.compressor != nil {
+		z.compressor.Reset(w)
+	}
+}
+
+// Write writes data to the zlib stream, the data is buffered and compressed block by block.
+// The header is written by the first Write.
+func (z *Zlib) Write(data []byte) (n int, err error) {
+	return z.stream.write(data, z.writeBlock)
+}
+
+// Flush compresses all pending data and aligns the stream to a byte boundary,
+// so all data written so far can be decompressed by the reader. It's like zlib.Writer.Flush.
+func (z *Zlib) Flush() error {
+	return z.stream.flush(z.writeBlock)
+}
+
+// Close compresses the pending data as the final block and writes the zlib trailer.
+// It does nothing if the stream has been finished (e.g. by ReadFrom).
+//
+// Close does not close the underlying writer like flate.Writer,
+// but the BufWriter (e.g. NewZlibWriter) closes it.
+func (z *Zlib) Close() error {
+	return z.stream.close(z.writeBlock)
+}
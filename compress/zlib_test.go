@@ -0,0 +1,85 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compress
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/intel/ixl-go/internal/testutil"
+)
+
+func TestZlibHeader(t *testing.T) {
+	for level := HuffmanOnlyLevel; level <= BestCompression; level++ {
+		buf := bytes.NewBuffer(nil)
+		w, err := zlib.NewWriterLevel(buf, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		hdr := appendZlibHeader(nil, level, nil)
+		if !bytes.Equal(hdr, buf.Bytes()[:2]) {
+			t.Fatalf("level %d: expected header %x, got %x", level, buf.Bytes()[:2], hdr)
+		}
+	}
+}
+
+func TestZlib_ReadFrom(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	z := NewZlib(io.Discard)
+	w := NewZlibWriter(io.Discard)
+	for i := 2; i < 1024*1024; i = i * 2 {
+		input := []byte(testutil.RandomText(i))
+		output := bytes.NewBuffer(nil)
+		z.Reset(output)
+		_, err := z.ReadFrom(bytes.NewReader(input))
+		if err != io.EOF && err != nil {
+			t.Fatal("error happened while compressing:", err)
+		}
+		testZlibOutput(t, output, input)
+
+		output.Reset()
+		w.Reset(output)
+		_, err = w.Write(input)
+		if err != nil {
+			t.Fatal("error happened while compressing:", err)
+		}
+		w.Close()
+		testZlibOutput(t, output, input)
+	}
+}
+
+func TestZlib_CompressAll(t *testing.T) {
+	if !Ready() {
+		t.Skip("IAA devices not found")
+	}
+	z := NewZlib(nil)
+	for i := 2; i < 1024*1024; i = i * 2 {
+		input := []byte(testutil.RandomText(i))
+		output, err := z.CompressAll(nil, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testZlibOutput(t, bytes.NewBuffer(output), input)
+	}
+}
+
+func testZlibOutput(t *testing.T, output *bytes.Buffer, input []byte) {
+	t.Helper()
+	r, err := zlib.NewReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal("error happened while decompressing:", err)
+	}
+	if !bytes.Equal(data, input) {
+		t.Fatal("decompressed data is not consistent with input")
+	}
+}
@@ -11,6 +11,9 @@ import (
 // An Error represents a ixl-go error.
 type Error = errors.Error
 
+// LimitError is returned when a limit (e.g. the decompression limits) is exceeded.
+type LimitError = errors.LimitError
+
 var (
 	// DataSizeTooLarge represents that data size is large than device's max_transfer_size
 	DataSizeTooLarge error = errors.SimpleError("data size is large than device's max_transfer_size")
@@ -20,6 +23,8 @@ var (
 	NoHardwareDeviceDetected error = errors.SimpleError("no hardware device detected")
 	// BufferSizeTooSmall represents that buffer size is too small.
 	BufferSizeTooSmall error = errors.SimpleError("buffer size too small")
+	// InvalidCompressionLevel represents that the compression level is not supported.
+	InvalidCompressionLevel error = errors.SimpleError("invalid compression level")
 )
 
 var (
@@ -28,4 +33,18 @@ var (
 	ErrNonLatin1Header = errors.SimpleError("gzip: non-Latin-1 header string")
 	// ErrZeroByte means the header string must not contains any zero byte.
 	ErrZeroByte = errors.SimpleError("gzip: header string contains zero byte")
+	// ErrWriterClosed means the writer has been closed.
+	ErrWriterClosed = errors.SimpleError("write to closed writer")
+	// ErrChecksum means the checksum of the decompressed data is invalid.
+	ErrChecksum = errors.SimpleError("invalid checksum")
+	// ErrBGZFHeader means the BGZF block header is invalid.
+	ErrBGZFHeader = errors.SimpleError("bgzf: invalid block header")
+	// ErrGzipHeader means the gzip header is invalid.
+	ErrGzipHeader = errors.SimpleError("gzip: invalid header")
+	// ErrZlibHeader means the zlib header is invalid.
+	ErrZlibHeader = errors.SimpleError("zlib: invalid header")
+	// ErrDictionary means the preset dictionary is missing or mismatches the dictionary identifier.
+	ErrDictionary = errors.SimpleError("zlib: invalid dictionary")
+	// ErrCorruptedData means the deflate stream is corrupted.
+	ErrCorruptedData = errors.SimpleError("flate: corrupted data")
 )
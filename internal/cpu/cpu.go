@@ -0,0 +1,13 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package cpu detects the CPU features used by the assembly implementations.
+// All the features are reported as unavailable on the other architectures or with the purego build tag.
+package cpu
+
+// X86 contains the x86 CPU features, they are detected when the package is initialized.
+var X86 struct {
+	HasSSE2    bool // SSE2, always available on amd64
+	HasAVX2    bool // AVX2 supported by both the CPU and the OS
+	HasAVX512F bool // AVX-512 Foundation supported by both the CPU and the OS
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !purego
+
+package cpu
+
+// cpuid executes the CPUID instruction with the leaf eaxArg and the sub-leaf ecxArg.
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// xgetbv reads the XCR0 register, the caller must check OSXSAVE first.
+func xgetbv() (eax, edx uint32)
+
+const (
+	// CPUID.1:ECX
+	bitOSXSAVE = 1 << 27
+	bitAVX     = 1 << 28
+	// CPUID.(7,0):EBX
+	bitAVX2    = 1 << 5
+	bitAVX512F = 1 << 16
+	// XCR0
+	xcr0SSE    = 1 << 1
+	xcr0AVX    = 1 << 2
+	xcr0AVX512 = 1<<5 | 1<<6 | 1<<7 // opmask, the upper halves of ZMM0-15, ZMM16-31
+)
+
+func init() {
+	X86.HasSSE2 = true
+
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 7 {
+		return
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	if ecx1&bitOSXSAVE == 0 || ecx1&bitAVX == 0 {
+		return
+	}
+	xcr0, _ := xgetbv()
+	osAVX := xcr0&(xcr0SSE|xcr0AVX) == xcr0SSE|xcr0AVX
+	osAVX512 := osAVX && xcr0&xcr0AVX512 == xcr0AVX512
+
+	_, ebx7, _, _ := cpuid(7, 0)
+	X86.HasAVX2 = osAVX && ebx7&bitAVX2 != 0
+	X86.HasAVX512F = osAVX512 && ebx7&bitAVX512F != 0
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package cpu
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestX86(t *testing.T) {
+	t.Logf("%+v", X86)
+	if runtime.GOARCH != "amd64" && (X86.HasSSE2 || X86.HasAVX2 || X86.HasAVX512F) {
+		t.Fatal("x86 features detected on", runtime.GOARCH)
+	}
+}
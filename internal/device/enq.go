@@ -8,7 +8,6 @@ import (
 	"os"
 	"runtime"
 	"sync/atomic"
-	"syscall"
 	"unsafe"
 
 	"github.com/intel/ixl-go/internal/config"
@@ -28,10 +27,16 @@ type Context struct {
 type submitter interface {
 	Submit(desc uintptr, comp *CompletionRecordHeader) (status uint8)
 	SubmitBusyPoll(desc uintptr, comp *CompletionRecordHeader) (status uint8)
+	Enqueue(desc uintptr, comp *CompletionRecordHeader)
+	Wait(comp *CompletionRecordHeader, busyPoll bool) (status uint8)
 }
 
 // CreateContext creates a new context instance given the device type.
 func CreateContext(typ config.DeviceType) *Context {
+	if !enqueueSupported {
+		log.Debug("enqueue instructions are not supported on this platform")
+		return nil
+	}
 	c := &Context{typ: typ}
 	c.init()
 	if len(c.wqs) == 0 {
@@ -81,16 +86,11 @@ func (c *Context) init() {
 		if !m.match(wq) {
 			continue
 		}
-		fd, err := syscall.Open(wq.DevicePath(), syscall.O_RDWR, 0)
+		fd, register, err := openWQ(wq)
 		if err != nil {
 			log.Debug("open %s failed: %v\n", wq.DevicePath(), err)
 			continue
 		}
-		register, err := initWQRegister(fd)
-		if err != nil {
-			log.Debug("init wq register failed: %v\n", err)
-			continue
-		}
 		if c.maxTransferSize == 0 {
 			c.maxTransferSize = uint32(wq.MaxTransferSize)
 		} else if wq.MaxTransferSize < uint64(c.maxTransferSize) {
@@ -132,88 +132,119 @@ func (c *Context) SubmitBusyPoll(desc uintptr, comp *CompletionRecordHeader) uin
 	return c.processors[idx].SubmitBusyPoll(desc, comp)
 }
 
-// initWQRegister initializes a new work queue register.
-func initWQRegister(fd int) ([]byte, error) {
-	return syscall.Mmap(fd, 0, 0x1000, syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+// Job is a request submitted by SubmitAsync, its completion must be waited by Wait.
+type Job struct {
+	s    submitter
+	comp *CompletionRecordHeader
 }
 
-// enqcmd enqueues a descriptor.
-func enqcmd(ctx *byte, desc uintptr) bool
-
-// endcmdWithRetry retries enqueuing a new command.
-func endcmdWithRetry(ctx *byte, desc uintptr) bool
+// SubmitAsync submits a new request with the given descriptor and completion record header without waiting the result,
+// so the caller can do other work while the device is processing the request.
+// The descriptor can be reused once SubmitAsync returns,
+// but the completion record and the buffers used by the request must be kept until the Job is waited.
+//
+// The request takes a slot of a dedicated work queue until the device completes it, not until it's waited,
+// so the caller may submit other requests while the Job is in flight, even if the work queue has only one slot.
+func (c *Context) SubmitAsync(desc uintptr, comp *CompletionRecordHeader) Job {
+	idx := int(atomic.AddUint64(&c.queue, 1) % uint64(len(c.processors)))
+	c.processors[idx].Enqueue(desc, comp)
+	return Job{s: c.processors[idx], comp: comp}
+}
 
-// waitForComplete waits for the request to complete.
-func waitForComplete(comp *uint64) (status uint8)
+// Wait waits the result of the job, by busy-polling if busyPoll is true.
+func (j Job) Wait(busyPoll bool) (status uint8) {
+	return j.s.Wait(j.comp, busyPoll)
+}
 
-// movdir64b enqueues a descriptor
-func movdir64b(ctx *byte, desc uintptr)
+// waitForCompleteYield waits for the request to complete, and yields the processor while waiting.
+func waitForCompleteYield(comp *CompletionRecordHeader) (status uint8) {
+	uip := (*uint64)(unsafe.Pointer(comp))
+	for {
+		runtime.Gosched()
+		hdr := atomic.LoadUint64(uip)
+		h := (*CompletionRecordHeader)(unsafe.Pointer(&hdr))
+		if h.ComplexStatus == 0 {
+			continue
+		}
+		return h.ComplexStatus & 0b00011111
+	}
+}
 
 func newDWQSubmitter(max int32, register []byte) (p *dwqSubmitter) {
 	p = &dwqSubmitter{}
-	p.max = max
+	p.slots = make([]atomic.Pointer[CompletionRecordHeader], max)
 	p.register = register
+	p.movdir64b = movdir64b
 	return p
 }
 
+// dwqSubmitter submits the requests to a dedicated work queue,
+// a descriptor submitted to a full dedicated work queue is dropped by the device,
+// so the requests in the work queue are tracked by the slots.
 type dwqSubmitter struct {
-	max      int32
-	sem      atomic.Int32
-	register []byte
+	slots     []atomic.Pointer[CompletionRecordHeader] // the requests in the work queue, nil if the slot is free
+	register  []byte
+	movdir64b func(register *byte, desc uintptr) // replaced by the tests
 }
 
 // Submit submits a new request with the given descriptor and completion record header and wait the result.
 func (p *dwqSubmitter) Submit(desc uintptr, comp *CompletionRecordHeader) (status uint8) {
-	// clear status
-	comp.ComplexStatus = 0
-	uip := (*uint64)(unsafe.Pointer(comp))
-	for {
-		s := p.sem.Load()
-		if s >= p.max {
-			runtime.Gosched()
-			continue
-		}
-		if p.sem.CompareAndSwap(s, s+1) {
-			break
-		}
-	}
-	movdir64b(&p.register[0], desc)
-	for {
-		runtime.Gosched()
-		hdr := atomic.LoadUint64(uip)
-		h := (*CompletionRecordHeader)(unsafe.Pointer(&hdr))
-		if h.ComplexStatus == 0 {
-			continue
-		}
-		p.sem.Add(-1)
-		status := h.ComplexStatus & 0b00011111
-		return status
-	}
+	p.Enqueue(desc, comp)
+	return p.Wait(comp, false)
 }
 
 // SubmitBusyPoll submits a new request with the given descriptor and completion record header
 // and wait the result by busy-polling.
 // This method may cause higher CPU cost.
 func (p *dwqSubmitter) SubmitBusyPoll(desc uintptr, comp *CompletionRecordHeader) (status uint8) {
-	// clear status
+	p.Enqueue(desc, comp)
+	return p.Wait(comp, true)
+}
+
+// Enqueue submits a new request with the given descriptor and completion record header without waiting the result,
+// it waits if the work queue is full.
+func (p *dwqSubmitter) Enqueue(desc uintptr, comp *CompletionRecordHeader) {
+	// clear status, the slot of the request is not free until it's completed
 	comp.ComplexStatus = 0
-	uip := (*uint64)(unsafe.Pointer(comp))
-	for {
-		s := p.sem.Load()
-		if s >= p.max {
-			runtime.Gosched()
-			continue
-		}
-		if p.sem.CompareAndSwap(s, s+1) {
-			break
+	for !p.acquire(comp) {
+		runtime.Gosched()
+	}
+	p.movdir64b(&p.register[0], desc)
+}
+
+// acquire takes a free slot for the request, it returns false if the work queue is full.
+// A slot is free once its request is completed by the device, even if the request is never waited
+// (e.g. the request in flight of a dropped object), so the requests waiting for slots never wait for each other.
+func (p *dwqSubmitter) acquire(comp *CompletionRecordHeader) bool {
+	for i := range p.slots {
+		old := p.slots[i].Load()
+		if (old == nil || completed(old)) && p.slots[i].CompareAndSwap(old, comp) {
+			return true
 		}
 	}
-	movdir64b(&p.register[0], desc)
-	status = waitForComplete(uip)
-	p.sem.Add(-1)
+	return false
+}
+
+// Wait waits the result of the request submitted by Enqueue.
+func (p *dwqSubmitter) Wait(comp *CompletionRecordHeader, busyPoll bool) (status uint8) {
+	if busyPoll {
+		status = waitForComplete((*uint64)(unsafe.Pointer(comp)))
+	} else {
+		status = waitForCompleteYield(comp)
+	}
+	// release the slot, so it's not taken by the completion record reset for the next request
+	for i := range p.slots {
+		p.slots[i].CompareAndSwap(comp, nil)
+	}
 	return status
 }
 
+// completed reports whether the request of the completion record is completed by the device.
+func completed(comp *CompletionRecordHeader) bool {
+	hdr := atomic.LoadUint64((*uint64)(unsafe.Pointer(comp)))
+	return (*CompletionRecordHeader)(unsafe.Pointer(&hdr)).ComplexStatus != 0
+}
+
 // swqSubmitter represents a swqSubmitter of the context.
 type swqSubmitter struct {
 	register []byte // Register.
@@ -233,35 +264,37 @@ func (p *swqSubmitter) SubmitBusyPoll(desc uintptr, comp *CompletionRecordHeader
 	// clear status
 	comp.ComplexStatus = 0
 
-	uip := (*uint64)(unsafe.Pointer(comp))
 	ret := endcmdWithRetry(&p.register[0], desc)
 	if ret {
 		panic("unexpected ENQCMD return value")
 	}
-	status = waitForComplete(uip)
-	return status
+	return p.Wait(comp, true)
 }
 
 // Submit submits a new request with the given descriptor and completion record header and wait the result.
 func (p *swqSubmitter) Submit(desc uintptr, comp *CompletionRecordHeader) (status uint8) {
+	p.Enqueue(desc, comp)
+	return p.Wait(comp, false)
+}
+
+// Enqueue submits a new request with the given descriptor and completion record header without waiting the result,
+// it retries if the work queue is full.
+func (p *swqSubmitter) Enqueue(desc uintptr, comp *CompletionRecordHeader) {
 	// clear status
 	comp.ComplexStatus = 0
 
-	uip := (*uint64)(unsafe.Pointer(comp))
 	for enqcmd(&p.register[0], desc) {
 		// go cannot setup goroutine's priority
 		runtime.Gosched()
 	}
-	for {
-		runtime.Gosched()
-		hdr := atomic.LoadUint64(uip)
-		h := (*CompletionRecordHeader)(unsafe.Pointer(&hdr))
-		if h.ComplexStatus == 0 {
-			continue
-		}
-		status := h.ComplexStatus & 0b00011111
-		return status
+}
+
+// Wait waits the result of the request submitted by Enqueue.
+func (p *swqSubmitter) Wait(comp *CompletionRecordHeader, busyPoll bool) (status uint8) {
+	if busyPoll {
+		return waitForComplete((*uint64)(unsafe.Pointer(comp)))
 	}
+	return waitForCompleteYield(comp)
 }
 
 // CompletionRecordHeader represents the completion record header.
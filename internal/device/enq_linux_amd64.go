@@ -0,0 +1,46 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !purego
+
+package device
+
+import (
+	"syscall"
+
+	"github.com/intel/ixl-go/internal/config"
+)
+
+// enqueueSupported reports whether the descriptors can be submitted on this platform.
+const enqueueSupported = true
+
+// openWQ opens the work queue and maps its register.
+func openWQ(wq *config.WorkQueue) (fd int, register []byte, err error) {
+	fd, err = syscall.Open(wq.DevicePath(), syscall.O_RDWR, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	register, err = initWQRegister(fd)
+	if err != nil {
+		syscall.Close(fd)
+		return 0, nil, err
+	}
+	return fd, register, nil
+}
+
+// initWQRegister initializes a new work queue register.
+func initWQRegister(fd int) ([]byte, error) {
+	return syscall.Mmap(fd, 0, 0x1000, syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+}
+
+// enqcmd enqueues a descriptor.
+func enqcmd(ctx *byte, desc uintptr) bool
+
+// endcmdWithRetry retries enqueuing a new command.
+func endcmdWithRetry(ctx *byte, desc uintptr) bool
+
+// waitForComplete waits for the request to complete.
+func waitForComplete(comp *uint64) (status uint8)
+
+// movdir64b enqueues a descriptor
+func movdir64b(ctx *byte, desc uintptr)
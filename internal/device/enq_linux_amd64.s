@@ -1,3 +1,5 @@
+//go:build !purego
+
 TEXT ·endcmdWithRetry(SB), $0-16
     MOVQ a+0(FP),AX
     MOVQ b+8(FP),BX
@@ -0,0 +1,46 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !linux || !amd64 || purego
+
+package device
+
+import (
+	"errors"
+
+	"github.com/intel/ixl-go/internal/config"
+)
+
+// enqueueSupported reports whether the descriptors can be submitted on this platform.
+// The enqueue instructions are implemented in assembly for linux/amd64 only,
+// so the devices are reported as unavailable and no context can be created.
+const enqueueSupported = false
+
+var errUnsupported = errors.New("work queues are not supported on this platform")
+
+// openWQ opens the work queue and maps its register.
+func openWQ(wq *config.WorkQueue) (fd int, register []byte, err error) {
+	return 0, nil, errUnsupported
+}
+
+// The stubs below are never called, since no context can be created.
+
+// enqcmd enqueues a descriptor.
+func enqcmd(ctx *byte, desc uintptr) bool {
+	panic(errUnsupported)
+}
+
+// endcmdWithRetry retries enqueuing a new command.
+func endcmdWithRetry(ctx *byte, desc uintptr) bool {
+	panic(errUnsupported)
+}
+
+// waitForComplete waits for the request to complete.
+func waitForComplete(comp *uint64) (status uint8) {
+	panic(errUnsupported)
+}
+
+// movdir64b enqueues a descriptor
+func movdir64b(ctx *byte, desc uintptr) {
+	panic(errUnsupported)
+}
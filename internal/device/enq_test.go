@@ -0,0 +1,108 @@
+// Copyright (c) 2023, Intel Corporation.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package device
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// success is the status of the successful request.
+const success = 1
+
+// fakeDWQ is a dedicated work queue with the given size,
+// the submitted requests are completed by the device after a while.
+type fakeDWQ struct {
+	mu       sync.Mutex
+	requests map[uintptr]*CompletionRecordHeader // the completion records of the descriptors
+	inflight atomic.Int32
+	overflow atomic.Bool
+	size     int32
+}
+
+func newFakeDWQ(size int32) (*fakeDWQ, *Context) {
+	q := &fakeDWQ{requests: map[uintptr]*CompletionRecordHeader{}, size: size}
+	p := newDWQSubmitter(size, make([]byte, 64))
+	p.movdir64b = q.movdir64b
+	return q, &Context{processors: []submitter{p}}
+}
+
+func (q *fakeDWQ) expect(desc uintptr, comp *CompletionRecordHeader) {
+	q.mu.Lock()
+	q.requests[desc] = comp
+	q.mu.Unlock()
+}
+
+func (q *fakeDWQ) movdir64b(_ *byte, desc uintptr) {
+	q.mu.Lock()
+	comp := q.requests[desc]
+	q.mu.Unlock()
+	// the device drops the descriptor submitted to a full work queue
+	if q.inflight.Add(1) > q.size {
+		q.overflow.Store(true)
+	}
+	go func() {
+		time.Sleep(time.Millisecond)
+		q.inflight.Add(-1)
+		atomic.StoreUint64((*uint64)(unsafe.Pointer(comp)), success)
+	}()
+}
+
+func TestDWQSubmitter_Size1(t *testing.T) {
+	q, ctx := newFakeDWQ(1)
+	encode, stats := &CompletionRecordHeader{}, &CompletionRecordHeader{}
+	q.expect(1, encode)
+	q.expect(2, stats)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the statistic job of the next block is submitted while the encode job is in flight
+		job := ctx.SubmitAsync(1, encode)
+		if status := ctx.Submit(2, stats); status != success {
+			t.Errorf("unexpected status %d of the statistic job", status)
+		}
+		if status := job.Wait(false); status != success {
+			t.Errorf("unexpected status %d of the encode job", status)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the job waiting for the work queue is deadlocked by the job in flight")
+	}
+	if q.overflow.Load() {
+		t.Fatal("the descriptor is submitted to the full work queue")
+	}
+}
+
+func TestDWQSubmitter_Dropped(t *testing.T) {
+	q, ctx := newFakeDWQ(2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the jobs of the dropped objects are never waited
+		for i := uintptr(1); i <= 10; i++ {
+			comp := &CompletionRecordHeader{}
+			q.expect(i, comp)
+			_ = ctx.SubmitAsync(i, comp)
+		}
+		comp := &CompletionRecordHeader{}
+		q.expect(11, comp)
+		if status := ctx.Submit(11, comp); status != success {
+			t.Errorf("unexpected status %d", status)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the slots of the jobs never waited are leaked")
+	}
+	if q.overflow.Load() {
+		t.Fatal("the descriptor is submitted to the full work queue")
+	}
+}
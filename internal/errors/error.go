@@ -17,6 +17,7 @@ type Error interface {
 var (
 	_ Error = SimpleError("")
 	_ Error = HardwareError{}
+	_ Error = &LimitError{}
 )
 
 // SimpleError is a simple implementation of Error.
@@ -42,3 +43,15 @@ func (h HardwareError) Error() string {
 }
 
 func (h HardwareError) isIXLGoError() {}
+
+// LimitError is an implementation of Error reporting that a configured limit is exceeded.
+type LimitError struct {
+	Limit string // Limit represents the name of the limit.
+	Value int64  // Value represents the value of the limit.
+}
+
+func (l *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %d", l.Limit, l.Value)
+}
+
+func (l *LimitError) isIXLGoError() {}
@@ -1,3 +1,5 @@
+//go:build !purego
+
 
 TEXT ·ENQCMD(SB), $0-16
     MOVQ a+0(FP),AX